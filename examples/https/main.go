@@ -2,12 +2,10 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
-	"path"
 	"syscall"
 	"time"
 
@@ -51,39 +49,17 @@ func StartServer(stop chan struct{}, logger *log.Logger) {
 	logger.Fatal(s.ListenAndServeTLS(CertFile, KeyFile))
 }
 
-var _ rest.Object = &Rest{}
-var _ rest.ObjectList = &RestList{}
-
 type RestList struct {
 	Items []*Rest `json:"items"`
 }
 
-func (r *RestList) TypeLink() string {
-	return "/rest"
-}
-
-func (r *RestList) Parse(bt []byte) error {
-	return json.Unmarshal(bt, &r)
-}
-
 type Rest struct {
 	Name string `json:"name"`
 }
 
-func (r *Rest) TypeLink(segments ...string) string {
-	return "/rest"
-}
-
-func (r *Rest) SelfLink(segments ...string) string {
-	return path.Join("/rest", r.Name)
-}
-
-func (r *Rest) Data() ([]byte, error) {
-	return json.Marshal(r)
-}
-
-func (r *Rest) Parse(bt []byte) error {
-	return json.Unmarshal(bt, &r)
+var RestResource = rest.Resource[Rest]{
+	Path: "/rest",
+	Name: func(r *Rest) string { return r.Name },
 }
 
 func main() {
@@ -99,7 +75,7 @@ func main() {
 	}()
 
 	go func() {
-		sig := make(chan os.Signal)
+		sig := make(chan os.Signal, 1)
 		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGHUP)
 		<-sig
 		stop <- struct{}{}
@@ -111,12 +87,14 @@ func main() {
 
 	cfg := config.GetConfigOrDie(ServerAddress, &c)
 
-	cli, err := rest.NewForConfig(cfg)
+	restCli, err := rest.NewForConfig(cfg)
 
 	if nil != err {
 		logger.Fatal(err)
 	}
 
+	cli := rest.NewTypedClient[Rest, RestList](restCli, RestResource)
+
 	obj := &Rest{}
 
 	err = cli.Create(context.TODO(), obj, &types.Options{})
@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/rest"
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+// DefaultErrorBackoff is the wait before listing again after a failure, or after a watch ended.
+const DefaultErrorBackoff = time.Second
+
+// ResourceEventHandler handles the changes of the objects of an informer.
+type ResourceEventHandler interface {
+	OnAdd(obj rest.Object)
+	OnUpdate(oldObj, newObj rest.Object)
+	OnDelete(obj rest.Object)
+}
+
+// ResourceEventHandlerFuncs is a ResourceEventHandler calling the functions which are not nil.
+type ResourceEventHandlerFuncs struct {
+	AddFunc    func(obj rest.Object)
+	UpdateFunc func(oldObj, newObj rest.Object)
+	DeleteFunc func(obj rest.Object)
+}
+
+// OnAdd implements ResourceEventHandler
+func (f ResourceEventHandlerFuncs) OnAdd(obj rest.Object) {
+	if nil != f.AddFunc {
+		f.AddFunc(obj)
+	}
+}
+
+// OnUpdate implements ResourceEventHandler
+func (f ResourceEventHandlerFuncs) OnUpdate(oldObj, newObj rest.Object) {
+	if nil != f.UpdateFunc {
+		f.UpdateFunc(oldObj, newObj)
+	}
+}
+
+// OnDelete implements ResourceEventHandler
+func (f ResourceEventHandlerFuncs) OnDelete(obj rest.Object) {
+	if nil != f.DeleteFunc {
+		f.DeleteFunc(obj)
+	}
+}
+
+// Config configures an Informer.
+type Config struct {
+	Client rest.Client
+	// NewList creates the list the collection is listed into.
+	NewList func() rest.ObjectList
+	// Watch is an object of the collection. If it's set the collection is watched
+	// between lists, otherwise it's listed every ResyncPeriod. The watch starts from
+	// the resourceVersion of the list if it's a rest.ResourceVersioned, otherwise the
+	// changes made between the list and the watch are only seen by the next list.
+	Watch rest.Object
+	// ResyncPeriod is how often the collection is listed again, zero means never
+	// if it's watched. Every object is handled as updated on a resync.
+	ResyncPeriod time.Duration
+	// Option is applied to the list and watch requests.
+	Option types.Option
+	// Indexers index the objects of the store.
+	Indexers Indexers
+	// ErrorBackoff is the wait before listing again after a failure, or after a watch
+	// ended by the server, DefaultErrorBackoff if zero.
+	ErrorBackoff time.Duration
+	// ErrorHandler is called with the errors of lists and watches.
+	ErrorHandler func(err error)
+}
+
+// Informer keeps a Store of the objects of a collection up to date by listing,
+// and optionally watching, it, and notifies handlers of the changes.
+//
+// Handlers are called one at a time, after the store is updated and unlocked, so they
+// could read the informer. They must not block, nor add handlers.
+type Informer struct {
+	config Config
+	store  *Store
+
+	// notifying serializes the changes with the delivery of their notifications
+	notifying sync.Mutex
+	lock      sync.RWMutex
+	handlers  []ResourceEventHandler
+	synced    bool
+}
+
+// notification is a change to notify handlers of.
+type notification struct {
+	handlers []ResourceEventHandler
+	old, obj rest.Object
+	deleted  bool
+}
+
+// notify delivers notifications in order, the notifying lock must be held.
+func notify(notifications []notification) {
+	for _, n := range notifications {
+		for _, handler := range n.handlers {
+			switch {
+			case n.deleted:
+				handler.OnDelete(n.obj)
+			case nil == n.old:
+				handler.OnAdd(n.obj)
+			default:
+				handler.OnUpdate(n.old, n.obj)
+			}
+		}
+	}
+}
+
+// NewInformer creates an informer.
+func NewInformer(config Config) *Informer {
+	if 0 == config.ErrorBackoff {
+		config.ErrorBackoff = DefaultErrorBackoff
+	}
+	return &Informer{config: config, store: NewStore(config.Indexers)}
+}
+
+// Store returns the store of the informer, which must not be modified.
+func (i *Informer) Store() *Store {
+	return i.store
+}
+
+// AddEventHandler adds handler, which is notified of the objects in the store as added.
+func (i *Informer) AddEventHandler(handler ResourceEventHandler) {
+	i.notifying.Lock()
+	defer i.notifying.Unlock()
+	i.lock.Lock()
+	i.handlers = append(i.handlers, handler)
+	notifications := []notification{}
+	for _, obj := range i.store.List() {
+		notifications = append(notifications, notification{handlers: []ResourceEventHandler{handler}, obj: obj})
+	}
+	i.lock.Unlock()
+	notify(notifications)
+}
+
+// HasSynced returns true once the collection has been listed.
+func (i *Informer) HasSynced() bool {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.synced
+}
+
+// WaitForSync waits until the collection has been listed, it returns false if ctx is done first.
+func (i *Informer) WaitForSync(ctx context.Context) bool {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !i.HasSynced() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// Run lists and watches the collection until ctx is done.
+func (i *Informer) Run(ctx context.Context) {
+	for nil == ctx.Err() {
+		wait := i.config.ResyncPeriod
+		resourceVersion, err := i.list(ctx)
+		if nil == err && nil != i.config.Watch {
+			var resync bool
+			resync, err = i.watch(ctx, resourceVersion)
+			wait = 0
+			if !resync {
+				wait = i.config.ErrorBackoff
+			}
+		}
+		if nil != err {
+			if nil != ctx.Err() {
+				return
+			}
+			if nil != i.config.ErrorHandler {
+				i.config.ErrorHandler(err)
+			}
+			wait = i.config.ErrorBackoff
+		}
+		if 0 == wait && nil == i.config.Watch {
+			<-ctx.Done()
+			return
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// list replaces the store with the collection, it returns the resourceVersion of the
+// list if it tells it.
+func (i *Informer) list(ctx context.Context) (string, error) {
+	list := i.config.NewList()
+	if err := i.config.Client.List(ctx, list, i.config.Option); nil != err {
+		return "", err
+	}
+	objects, err := rest.ListObjects(list)
+	if nil != err {
+		return "", err
+	}
+	resourceVersion := ""
+	if v, ok := list.(rest.ResourceVersioned); ok {
+		resourceVersion = v.GetResourceVersion()
+	}
+
+	i.notifying.Lock()
+	defer i.notifying.Unlock()
+	notifications, err := i.replace(objects)
+	notify(notifications)
+	return resourceVersion, err
+}
+
+// replace replaces the store with objects, it returns the notifications of the changes.
+func (i *Informer) replace(objects []rest.Object) ([]notification, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	notifications := []notification{}
+	listed := map[string]bool{}
+	for _, obj := range objects {
+		listed[obj.SelfLink()] = true
+		n, err := i.put(obj)
+		if nil != err {
+			return notifications, err
+		}
+		notifications = append(notifications, n)
+	}
+	for _, key := range i.store.Keys() {
+		if !listed[key] {
+			if n, ok := i.remove(key); ok {
+				notifications = append(notifications, n)
+			}
+		}
+	}
+	i.synced = true
+	return notifications, nil
+}
+
+// watch applies the events of the collection from resourceVersion, if any, until the watch
+// ends, or it's time to resync, in which case it returns true.
+func (i *Informer) watch(ctx context.Context, resourceVersion string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	option := i.config.Option
+	if len(resourceVersion) > 0 {
+		option = types.Merge(option, &types.ListOptions{ResourceVersion: resourceVersion})
+	}
+	events, err := i.config.Client.Watch(ctx, i.config.Watch, option)
+	if nil != err {
+		return false, err
+	}
+	var resync <-chan time.Time
+	if i.config.ResyncPeriod > 0 {
+		timer := time.NewTimer(i.config.ResyncPeriod)
+		defer timer.Stop()
+		resync = timer.C
+	}
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false, nil
+			}
+			if err := i.apply(event); nil != err {
+				return false, err
+			}
+		case <-resync:
+			return true, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+func (i *Informer) apply(event rest.Event) error {
+	if event.Type == http.Error {
+		return event.Err
+	}
+	i.notifying.Lock()
+	defer i.notifying.Unlock()
+	i.lock.Lock()
+	var n notification
+	var ok bool
+	var err error
+	switch event.Type {
+	case http.Added, http.Modified:
+		n, err = i.put(event.Object)
+		ok = nil == err
+	case http.Deleted:
+		n, ok = i.remove(event.Object.SelfLink())
+	}
+	i.lock.Unlock()
+	if ok {
+		notify([]notification{n})
+	}
+	return err
+}
+
+// put stores obj and returns the notification of the change, the lock must be held.
+func (i *Informer) put(obj rest.Object) (notification, error) {
+	old, err := i.store.put(obj)
+	if nil != err {
+		return notification{}, err
+	}
+	return notification{handlers: i.handlers, old: old, obj: obj}, nil
+}
+
+// remove deletes the object of key and returns the notification of the change, false if
+// there was no such object, the lock must be held.
+func (i *Informer) remove(key string) (notification, bool) {
+	obj := i.store.remove(key)
+	if nil == obj {
+		return notification{}, false
+	}
+	return notification{handlers: i.handlers, obj: obj, deleted: true}, true
+}
@@ -0,0 +1,254 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	fakehttp "github.com/alauda/kube-rest/pkg/http/fake"
+	"github.com/alauda/kube-rest/pkg/rest"
+	"github.com/alauda/kube-rest/pkg/rest/fake"
+)
+
+type testObj struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (t *testObj) TypeLink(segments ...string) string {
+	return "/test"
+}
+
+func (t *testObj) SelfLink(segments ...string) string {
+	return path.Join("/test", t.Name)
+}
+
+func (t *testObj) Data() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *testObj) Parse(bt []byte) error {
+	clone := new(testObj)
+	if err := json.Unmarshal(bt, clone); nil != err {
+		return err
+	}
+	*t = *clone
+	return nil
+}
+
+type testObjList struct {
+	Items []testObj `json:"items"`
+}
+
+func (t *testObjList) TypeLink() string {
+	return "/test"
+}
+
+func (t *testObjList) Parse(bt []byte) error {
+	clone := new(testObjList)
+	if err := json.Unmarshal(bt, clone); nil != err {
+		return err
+	}
+	*t = *clone
+	return nil
+}
+
+// versionedList is a list telling the resourceVersion it was listed at.
+type versionedList struct {
+	testObjList
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+}
+
+func (t *versionedList) Parse(bt []byte) error {
+	clone := new(versionedList)
+	if err := json.Unmarshal(bt, clone); nil != err {
+		return err
+	}
+	*t = *clone
+	return nil
+}
+
+func (t *versionedList) GetResourceVersion() string {
+	return t.Metadata.ResourceVersion
+}
+
+// recorder records the events handled as "type name id".
+type recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string, obj rest.Object) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	o := obj.(*testObj)
+	r.events = append(r.events, event+" "+o.Name+" "+o.ID)
+}
+
+func (r *recorder) handler() ResourceEventHandler {
+	return ResourceEventHandlerFuncs{
+		AddFunc: func(obj rest.Object) { r.record("add", obj) },
+		UpdateFunc: func(oldObj, newObj rest.Object) {
+			// a resync handles every object as updated
+			if !reflect.DeepEqual(oldObj, newObj) {
+				r.record("update", newObj)
+			}
+		},
+		DeleteFunc: func(obj rest.Object) { r.record("delete", obj) },
+	}
+}
+
+// wait waits for n events and returns them.
+func (r *recorder) wait(n int) []string {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		r.lock.Lock()
+		if len(r.events) >= n {
+			events := r.events
+			r.events = nil
+			r.lock.Unlock()
+			return events
+		}
+		r.lock.Unlock()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.events
+}
+
+func byID(obj rest.Object) ([]string, error) {
+	return []string{obj.(*testObj).ID}, nil
+}
+
+func TestInformer(t *testing.T) {
+	cases := []struct {
+		name   string
+		watch  rest.Object
+		resync time.Duration
+	}{
+		{name: "list_watch", watch: &testObj{}},
+		{name: "list", resync: 10 * time.Millisecond},
+	}
+
+	for _, c := range cases {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cli := fake.NewClient(&testObj{Name: "a", ID: "1"}, &testObj{Name: "b", ID: "1"})
+		informer := NewInformer(Config{
+			Client:       cli,
+			NewList:      func() rest.ObjectList { return &testObjList{} },
+			Watch:        c.watch,
+			ResyncPeriod: c.resync,
+			Indexers:     Indexers{"id": byID},
+		})
+		r := &recorder{}
+		informer.AddEventHandler(r.handler())
+		go informer.Run(ctx)
+
+		if !informer.WaitForSync(ctx) {
+			t.Errorf("Informer(%q) not synced", c.name)
+		}
+		if got, want := r.wait(2), []string{"add a 1", "add b 1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Informer(%q) want events: %v\ngot: %v", c.name, want, got)
+		}
+		if objects, _ := informer.Store().ByIndex("id", "1"); len(objects) != 2 {
+			t.Errorf("Informer(%q) got %d objects of id 1. wanted 2", c.name, len(objects))
+		}
+
+		cli.Create(ctx, &testObj{Name: "c", ID: "2"}, nil)
+		cli.Update(ctx, &testObj{Name: "a", ID: "2"}, nil)
+		cli.Delete(ctx, &testObj{Name: "b"}, nil)
+
+		want := []string{"add c 2", "update a 2", "delete b 1"}
+		got := r.wait(3)
+		if nil == c.watch {
+			// the changes may be listed at once, in the order of the objects
+			sort.Strings(want)
+			sort.Strings(got)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Informer(%q) want events: %v\ngot: %v", c.name, want, got)
+		}
+		if objects, _ := informer.Store().ByIndex("id", "2"); len(objects) != 2 {
+			t.Errorf("Informer(%q) got %d objects of id 2. wanted 2", c.name, len(objects))
+		}
+		if keys := informer.Store().Keys(); !reflect.DeepEqual(keys, []string{"/test/a", "/test/c"}) {
+			t.Errorf("Informer(%q) got keys %v", c.name, keys)
+		}
+		cancel()
+	}
+}
+
+func TestInformerHandlerReads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cli := fake.NewClient(&testObj{Name: "a", ID: "1"})
+	informer := NewInformer(Config{
+		Client:  cli,
+		NewList: func() rest.ObjectList { return &testObjList{} },
+		Watch:   &testObj{},
+	})
+	r := &recorder{}
+	informer.AddEventHandler(ResourceEventHandlerFuncs{
+		AddFunc: func(obj rest.Object) {
+			// the handler sees the store it's notified of, without deadlocking
+			if _, ok := informer.Store().Get(obj.SelfLink()); ok && informer.HasSynced() {
+				r.record("stored", obj)
+			}
+		},
+	})
+	go informer.Run(ctx)
+
+	if !informer.WaitForSync(ctx) {
+		t.Fatalf("Informer not synced")
+	}
+	cli.Create(ctx, &testObj{Name: "b", ID: "2"}, nil)
+	if got, want := r.wait(2), []string{"stored a 1", "stored b 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Informer want events: %v\ngot: %v", want, got)
+	}
+}
+
+func TestInformerWatchEnded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cli := fake.NewClient()
+	cli.PrependReactor(nethttp.MethodGet, "/test", func(action fakehttp.Action) (bool, *http.Response, error) {
+		return true, &http.Response{StatusCode: nethttp.StatusOK, Body: []byte(`{"metadata":{"resourceVersion":"5"},"items":[]}`)}, nil
+	})
+	// the server ends every watch right away
+	cli.PrependReactor(fakehttp.Watch, "/test", func(action fakehttp.Action) (bool, *http.Response, error) {
+		return true, nil, nil
+	})
+	informer := NewInformer(Config{
+		Client:       cli,
+		NewList:      func() rest.ObjectList { return &versionedList{} },
+		Watch:        &testObj{},
+		ErrorBackoff: 20 * time.Millisecond,
+	})
+	go informer.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	lists, watches := 0, 0
+	for _, action := range cli.Actions() {
+		switch action.Verb {
+		case nethttp.MethodGet:
+			lists++
+		case fakehttp.Watch:
+			watches++
+			if got := action.Query.Get("resourceVersion"); got != "5" {
+				t.Errorf("Informer watched from resourceVersion %q. wanted 5", got)
+			}
+		}
+	}
+	if lists < 2 || lists > 10 || watches < 2 {
+		t.Errorf("Informer got %d lists and %d watches in 50ms. wanted them backed off by 20ms", lists, watches)
+	}
+}
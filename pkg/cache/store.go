@@ -0,0 +1,148 @@
+// Package cache keeps local caches of rest collections up to date, like the informers of client-go.
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/alauda/kube-rest/pkg/rest"
+)
+
+// Indexer returns the values obj is indexed by.
+type Indexer func(obj rest.Object) ([]string, error)
+
+// Indexers are indexers by their names.
+type Indexers map[string]Indexer
+
+// Store is an indexed in-memory store of objects keyed by their SelfLink.
+type Store struct {
+	lock     sync.RWMutex
+	items    map[string]rest.Object
+	indexers Indexers
+	// indices are the keys of objects by their values of the indexers.
+	indices map[string]map[string]map[string]struct{}
+	// values are the values of the indexers by the keys of objects.
+	values map[string]map[string][]string
+}
+
+// NewStore creates an empty store with indexers.
+func NewStore(indexers Indexers) *Store {
+	s := &Store{
+		items:    map[string]rest.Object{},
+		indexers: Indexers{},
+		indices:  map[string]map[string]map[string]struct{}{},
+		values:   map[string]map[string][]string{},
+	}
+	for name, indexer := range indexers {
+		s.indexers[name] = indexer
+		s.indices[name] = map[string]map[string]struct{}{}
+	}
+	return s
+}
+
+// Get returns the object of key.
+func (s *Store) Get(key string) (rest.Object, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	obj, ok := s.items[key]
+	return obj, ok
+}
+
+// Keys returns the sorted keys of the objects.
+func (s *Store) Keys() []string {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.keys()
+}
+
+// List returns the objects ordered by their keys.
+func (s *Store) List() []rest.Object {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	objects := make([]rest.Object, 0, len(s.items))
+	for _, key := range s.keys() {
+		objects = append(objects, s.items[key])
+	}
+	return objects
+}
+
+// ByIndex returns the objects whose values of the indexer name include value, ordered by their keys.
+func (s *Store) ByIndex(name, value string) ([]rest.Object, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	index, ok := s.indices[name]
+	if !ok {
+		return nil, fmt.Errorf("indexer %q does not exist", name)
+	}
+	keys := make([]string, 0, len(index[value]))
+	for key := range index[value] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	objects := make([]rest.Object, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, s.items[key])
+	}
+	return objects, nil
+}
+
+func (s *Store) keys() []string {
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// put adds or replaces obj and returns the replaced one.
+func (s *Store) put(obj rest.Object) (rest.Object, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := obj.SelfLink()
+	old := s.items[key]
+	values := map[string][]string{}
+	for name, indexer := range s.indexers {
+		v, err := indexer(obj)
+		if nil != err {
+			return nil, fmt.Errorf("indexer %q failed for %s: %v", name, key, err)
+		}
+		values[name] = v
+	}
+	s.unindex(key)
+	s.items[key] = obj
+	s.values[key] = values
+	for name, v := range values {
+		for _, value := range v {
+			if nil == s.indices[name][value] {
+				s.indices[name][value] = map[string]struct{}{}
+			}
+			s.indices[name][value][key] = struct{}{}
+		}
+	}
+	return old, nil
+}
+
+// remove deletes the object of key and returns it.
+func (s *Store) remove(key string) rest.Object {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	obj := s.items[key]
+	s.unindex(key)
+	delete(s.items, key)
+	return obj
+}
+
+func (s *Store) unindex(key string) {
+	for name, values := range s.values[key] {
+		index := s.indices[name]
+		for _, value := range values {
+			delete(index[value], key)
+			if len(index[value]) == 0 {
+				delete(index, value)
+			}
+		}
+	}
+	delete(s.values, key)
+}
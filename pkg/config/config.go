@@ -3,6 +3,8 @@ package config
 import (
 	"net/url"
 
+	"github.com/alauda/kube-rest/pkg/types"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -66,3 +68,10 @@ func GetConfigOrDie(server string, certFile *string) *rest.Config {
 	}
 	return cfg
 }
+
+// ClientConfig is a rest config with the settings of the clients which rest.Config lacks.
+type ClientConfig struct {
+	*rest.Config
+	// RetryPolicy retries failed idempotent requests, nil leaves it to client-go.
+	RetryPolicy *types.RetryPolicy
+}
@@ -0,0 +1,293 @@
+package http
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// CachePolicy configures the response cache of NewCachingClient.
+type CachePolicy struct {
+	// TTL is how long a response without Cache-Control max-age or Expires is fresh.
+	TTL time.Duration
+	// MaxEntries bounds the number of cached responses, the least recently used
+	// ones are evicted first. Zero means no limit.
+	MaxEntries int
+}
+
+// cachingClient caches the responses of GET requests.
+type cachingClient struct {
+	Interface
+	policy CachePolicy
+	now    func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key     string
+	absPath string
+	resp    *Response
+	expires time.Time
+}
+
+// NewCachingClient wraps client with a cache of the responses of GET requests, keyed
+// by their path and the query and headers set by their option.
+//
+// Responses are cached according to their Cache-Control and Expires headers, or policy.TTL.
+// A stale response with an ETag or Last-Modified is revalidated with a conditional request.
+// Successful Create, Update, Patch and Delete invalidate the responses of the same path,
+// the paths under it and its collection.
+func NewCachingClient(client Interface, policy CachePolicy) Interface {
+	return &cachingClient{
+		Interface: client,
+		policy:    policy,
+		now:       time.Now,
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+	}
+}
+
+// Do implements Interface
+func (c *cachingClient) Do(ctx context.Context, request *Request) (*Response, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	if request.Verb != http.MethodGet {
+		resp, err := c.Interface.Do(ctx, request)
+		if nil == err {
+			c.invalidate(request.AbsPath)
+		}
+		return resp, err
+	}
+
+	key := cacheKey(request)
+	entry := c.get(key)
+	if nil != entry && c.now().Before(entry.expires) {
+		resp := entry.copyResponse()
+		recordResponse(ctx, resp)
+		return resp, nil
+	}
+	if nil != entry {
+		conditional := *request
+		conditional.Option = &headerOption{request.Option, entry.validators()}
+		resp, err := c.Interface.Do(ctx, &conditional)
+		if nil != resp && resp.StatusCode == http.StatusNotModified {
+			revalidated := entry.copyResponse()
+			revalidated.Header = mergeHeader(revalidated.Header, resp.Header)
+			c.put(key, request.AbsPath, revalidated)
+			recordResponse(ctx, revalidated)
+			return revalidated, nil
+		}
+		if nil == err {
+			c.put(key, request.AbsPath, resp)
+		}
+		return resp, err
+	}
+	resp, err := c.Interface.Do(ctx, request)
+	if nil == err {
+		c.put(key, request.AbsPath, resp)
+	}
+	return resp, err
+}
+
+func (c *cachingClient) Get(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodGet, AbsPath: absPath, Option: option}))
+}
+
+func (c *cachingClient) List(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodGet, AbsPath: absPath, Option: option, List: true}))
+}
+
+func (c *cachingClient) Create(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPost, AbsPath: absPath, Body: outBytes, Option: option}))
+}
+
+func (c *cachingClient) Update(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPut, AbsPath: absPath, Body: outBytes, Option: option}))
+}
+
+func (c *cachingClient) Patch(ctx context.Context, absPath string, pt types2.PatchType, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPatch, AbsPath: absPath, PatchType: pt, Body: outBytes, Option: option}))
+}
+
+func (c *cachingClient) Delete(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodDelete, AbsPath: absPath, Option: option}))
+}
+
+func (c *cachingClient) get(key string) *cacheEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry)
+	}
+	return nil
+}
+
+// put caches resp if its Cache-Control allows it.
+func (c *cachingClient) put(key, absPath string, resp *Response) {
+	if nil == resp {
+		return
+	}
+	directives := cacheControl(resp.Header)
+	if _, ok := directives["no-store"]; ok {
+		return
+	}
+	now := c.now()
+	expires := now.Add(c.policy.TTL)
+	if _, ok := directives["no-cache"]; ok {
+		expires = now
+	} else if maxAge, ok := directives["max-age"]; ok {
+		seconds, _ := strconv.Atoi(maxAge)
+		expires = now.Add(time.Duration(seconds) * time.Second)
+	} else if t, err := http.ParseTime(resp.Header.Get("Expires")); nil == err {
+		expires = t
+	}
+	entry := &cacheEntry{key: key, absPath: cleanPath(absPath), resp: resp, expires: expires}
+	if !now.Before(expires) && len(entry.validators()) == 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.policy.MaxEntries > 0 && c.lru.Len() > c.policy.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+// invalidate removes the responses of absPath, of the paths under it, e.g. the items of a
+// deleted collection, and of its collection.
+func (c *cachingClient) invalidate(absPath string) {
+	absPath = cleanPath(absPath)
+	collection := path.Dir(absPath)
+	prefix := strings.TrimSuffix(absPath, "/") + "/"
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for e := c.lru.Front(); nil != e; {
+		next := e.Next()
+		if p := e.Value.(*cacheEntry).absPath; p == absPath || p == collection || strings.HasPrefix(p, prefix) {
+			c.remove(e)
+		}
+		e = next
+	}
+}
+
+func (c *cachingClient) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).key)
+}
+
+// copyResponse returns a copy of the cached response, which could be modified by the caller.
+func (e *cacheEntry) copyResponse() *Response {
+	return &Response{
+		StatusCode: e.resp.StatusCode,
+		Header:     e.resp.Header.Clone(),
+		Body:       append([]byte(nil), e.resp.Body...),
+	}
+}
+
+// validators returns the headers revalidating the cached response.
+func (e *cacheEntry) validators() url.Values {
+	header := url.Values{}
+	if etag := e.resp.Header.Get("ETag"); len(etag) > 0 {
+		header.Set("If-None-Match", etag)
+	}
+	if modified := e.resp.Header.Get("Last-Modified"); len(modified) > 0 {
+		header.Set("If-Modified-Since", modified)
+	}
+	return header
+}
+
+// cacheKey returns the key of the response of request, made of its path and the query
+// and headers set by its header and option.
+func cacheKey(request *Request) string {
+	req, err := CaptureRequest(request.Verb, request.AbsPath, "", nil, &types.Options{Header: url.Values(request.Header)}, request.Option)
+	if nil != err {
+		return request.AbsPath
+	}
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var key strings.Builder
+	key.WriteString(req.URL.RequestURI())
+	for _, k := range keys {
+		key.WriteString("\n" + k + ": " + strings.Join(req.Header[k], ", "))
+	}
+	return key.String()
+}
+
+// cacheControl parses the Cache-Control directives of header.
+func cacheControl(header http.Header) map[string]string {
+	directives := map[string]string{}
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if len(directive) == 0 {
+				continue
+			}
+			name, arg := directive, ""
+			if i := strings.Index(directive, "="); i >= 0 {
+				name, arg = directive[:i], strings.Trim(directive[i+1:], `"`)
+			}
+			directives[strings.ToLower(name)] = arg
+		}
+	}
+	return directives
+}
+
+// mergeHeader updates the cached header with those of a 304 response.
+func mergeHeader(cached, updated http.Header) http.Header {
+	merged := cached.Clone()
+	for k, v := range updated {
+		merged[k] = v
+	}
+	return merged
+}
+
+func cleanPath(absPath string) string {
+	return path.Clean("/" + strings.TrimSuffix(absPath, "/"))
+}
+
+// headerOption applies option and then sets header.
+type headerOption struct {
+	option types.Option
+	header url.Values
+}
+
+func (o *headerOption) ApplyToRequest(req *rest.Request) *rest.Request {
+	if nil != o.option {
+		req = o.option.ApplyToRequest(req)
+	}
+	for k, v := range o.header {
+		req = req.SetHeader(k, v...)
+	}
+	return req
+}
+
+// IsIdempotent implements types.Idempotent
+func (o *headerOption) IsIdempotent() bool {
+	return types.IsIdempotent(o.option)
+}
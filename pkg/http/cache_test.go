@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+func TestCachingClient(t *testing.T) {
+	hits := map[string]int{}
+	version := "1"
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.RequestURI()
+		hits[key]++
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", version)
+			if r.Header.Get("If-None-Match") == version {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/ttl":
+		default:
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte(key + " " + version))
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	now := time.Now()
+	cache := NewCachingClient(cli, CachePolicy{TTL: time.Minute, MaxEntries: 4}).(*cachingClient)
+	cache.now = func() time.Time { return now }
+	ctx := context.TODO()
+	params := func(k, v string) types.Option {
+		return &types.Options{Params: types.QueryParameters{k: v}}
+	}
+
+	cases := []struct {
+		name string
+		do   func() ([]byte, error)
+		want string
+		hits map[string]int
+	}{
+		{
+			name: "max_age_miss",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/users/a", nil) },
+			want: "GET /users/a 1",
+			hits: map[string]int{"GET /users/a": 1},
+		},
+		{
+			name: "max_age_hit",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/users/a", nil) },
+			want: "GET /users/a 1",
+			hits: map[string]int{"GET /users/a": 1},
+		},
+		{
+			name: "option_miss",
+			do:   func() ([]byte, error) { return cache.List(ctx, "/users", params("limit", "1")) },
+			want: "GET /users?limit=1 1",
+			hits: map[string]int{"GET /users?limit=1": 1},
+		},
+		{
+			name: "option_hit",
+			do:   func() ([]byte, error) { return cache.List(ctx, "/users", params("limit", "1")) },
+			want: "GET /users?limit=1 1",
+			hits: map[string]int{"GET /users?limit=1": 1},
+		},
+		{
+			name: "update_invalidates",
+			do: func() ([]byte, error) {
+				version = "2"
+				return cache.Update(ctx, "/users/a", []byte("{}"), nil)
+			},
+			want: "PUT /users/a 2",
+			hits: map[string]int{"PUT /users/a": 1},
+		},
+		{
+			name: "invalidated_path",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/users/a", nil) },
+			want: "GET /users/a 2",
+			hits: map[string]int{"GET /users/a": 2},
+		},
+		{
+			name: "invalidated_collection",
+			do:   func() ([]byte, error) { return cache.List(ctx, "/users", params("limit", "1")) },
+			want: "GET /users?limit=1 2",
+			hits: map[string]int{"GET /users?limit=1": 2},
+		},
+		{
+			name: "item_miss",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/users/b", nil) },
+			want: "GET /users/b 2",
+			hits: map[string]int{"GET /users/b": 1},
+		},
+		{
+			name: "delete_collection_invalidates",
+			do: func() ([]byte, error) {
+				cache.List(ctx, "/users", params("limit", "1"))
+				return cache.Delete(ctx, "/users", nil)
+			},
+			want: "DELETE /users 2",
+			hits: map[string]int{"GET /users?limit=1": 2, "DELETE /users": 1},
+		},
+		{
+			name: "invalidated_items",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/users/b", nil) },
+			want: "GET /users/b 2",
+			hits: map[string]int{"GET /users/b": 2},
+		},
+		{
+			name: "invalidated_list",
+			do:   func() ([]byte, error) { return cache.List(ctx, "/users", params("limit", "1")) },
+			want: "GET /users?limit=1 2",
+			hits: map[string]int{"GET /users?limit=1": 3},
+		},
+		{
+			name: "etag_miss",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/etag", nil) },
+			want: "GET /etag 2",
+			hits: map[string]int{"GET /etag": 1},
+		},
+		{
+			name: "etag_revalidated",
+			do:   func() ([]byte, error) { return cache.Get(ctx, "/etag", nil) },
+			want: "GET /etag 2",
+			hits: map[string]int{"GET /etag": 2},
+		},
+		{
+			name: "etag_changed",
+			do: func() ([]byte, error) {
+				version = "3"
+				return cache.Get(ctx, "/etag", nil)
+			},
+			want: "GET /etag 3",
+			hits: map[string]int{"GET /etag": 3},
+		},
+		{
+			name: "no_store",
+			do: func() ([]byte, error) {
+				cache.Get(ctx, "/nostore", nil)
+				return cache.Get(ctx, "/nostore", nil)
+			},
+			want: "GET /nostore 3",
+			hits: map[string]int{"GET /nostore": 2},
+		},
+		{
+			name: "ttl_hit",
+			do: func() ([]byte, error) {
+				cache.Get(ctx, "/ttl", nil)
+				return cache.Get(ctx, "/ttl", nil)
+			},
+			want: "GET /ttl 3",
+			hits: map[string]int{"GET /ttl": 1},
+		},
+		{
+			name: "ttl_expired",
+			do: func() ([]byte, error) {
+				now = now.Add(time.Minute)
+				return cache.Get(ctx, "/ttl", nil)
+			},
+			want: "GET /ttl 3",
+			hits: map[string]int{"GET /ttl": 2},
+		},
+		{
+			name: "lru_evicted",
+			do: func() ([]byte, error) {
+				for _, p := range []string{"/b", "/c", "/d", "/e"} {
+					cache.Get(ctx, p, nil)
+				}
+				return cache.Get(ctx, "/ttl", nil)
+			},
+			want: "GET /ttl 3",
+			hits: map[string]int{"GET /ttl": 3, "GET /e": 1},
+		},
+	}
+
+	for _, c := range cases {
+		bt, err := c.do()
+		if nil != err {
+			t.Errorf("CachingClient(%q) got error %v", c.name, err)
+			continue
+		}
+		if string(bt) != c.want {
+			t.Errorf("CachingClient(%q) got %s. wanted %s", c.name, bt, c.want)
+		}
+		for k, v := range c.hits {
+			if hits[k] != v {
+				t.Errorf("CachingClient(%q) got %d hits of %s. wanted %d", c.name, hits[k], k, v)
+			}
+		}
+	}
+	if cache.lru.Len() != 4 {
+		t.Errorf("CachingClient got %d entries. wanted 4", cache.lru.Len())
+	}
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+// CaptureRequest returns the request client-go sends for a request of verb to absPath, with
+// the Content-Type pt, body and options applied in order, without sending it.
+func CaptureRequest(verb, absPath string, pt types2.PatchType, body []byte, options ...types.Option) (*http.Request, error) {
+	capture := &captureClient{}
+	req := rest.NewRequest(capture, verb, &url.URL{Path: "/"}, "", rest.ContentConfig{}, rest.Serializers{}, nil, nil, 0).AbsPath(absPath)
+	if len(pt) > 0 {
+		req = req.SetHeader("Content-Type", string(pt))
+	}
+	if nil != body {
+		req = req.Body(body)
+	}
+	for _, option := range options {
+		if nil != option {
+			req = option.ApplyToRequest(req)
+		}
+	}
+	if _, err := req.DoRaw(); nil != err {
+		return nil, err
+	}
+	return capture.req, nil
+}
+
+// captureClient captures the request sent with it instead of sending it.
+type captureClient struct {
+	req *http.Request
+}
+
+func (c *captureClient) Do(req *http.Request) (*http.Response, error) {
+	c.req = req
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(bytes.NewReader(nil))}, nil
+}
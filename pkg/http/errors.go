@@ -0,0 +1,159 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is the RFC 7807 description of an error.
+type ProblemDetails struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions are the members of the problem other than the ones above.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// HTTPError is the error of a request the server responded with a non-2xx status code.
+//
+// It implements the APIStatus of k8s.io/apimachinery/pkg/api/errors, so the helpers
+// there, like errors.IsNotFound, keep working for any backend.
+type HTTPError struct {
+	Method     string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Problem is the decoded problem details if the response is application/problem+json.
+	Problem *ProblemDetails
+	// KubeStatus is the decoded status if the response is a kubernetes Status.
+	KubeStatus *metav1.Status
+}
+
+var _ apiError.APIStatus = &HTTPError{}
+
+// newHTTPError creates a HTTPError from a response, decoding its problem details or status.
+func newHTTPError(method string, resp *Response, body []byte) *HTTPError {
+	e := &HTTPError{Method: method, StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+	if len(body) == 0 {
+		return e
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == ProblemContentType {
+		problem := &ProblemDetails{}
+		if err := json.Unmarshal(body, problem); nil == err {
+			if err := json.Unmarshal(body, &problem.Extensions); nil == err {
+				for _, member := range []string{"type", "title", "status", "detail", "instance"} {
+					delete(problem.Extensions, member)
+				}
+			}
+			e.Problem = problem
+		}
+		return e
+	}
+	status := &metav1.Status{}
+	if err := json.Unmarshal(body, status); nil == err && status.Kind == "Status" {
+		e.KubeStatus = status
+	}
+	return e
+}
+
+// Error implements error.
+func (e *HTTPError) Error() string {
+	switch {
+	case nil != e.KubeStatus && len(e.KubeStatus.Message) > 0:
+		return e.KubeStatus.Message
+	case nil != e.Problem && len(e.Problem.Detail) > 0:
+		return fmt.Sprintf("%s: %s", e.Problem.Title, e.Problem.Detail)
+	case nil != e.Problem && len(e.Problem.Title) > 0:
+		return e.Problem.Title
+	case len(e.Body) > 0:
+		return fmt.Sprintf("the server responded with status %d: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("the server responded with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Status implements apiError.APIStatus, for non-kubernetes responses the status is
+// derived from the status code.
+func (e *HTTPError) Status() metav1.Status {
+	if nil != e.KubeStatus {
+		return *e.KubeStatus
+	}
+	seconds := 0
+	if after, ok := e.RetryAfter(); ok {
+		seconds = int(after / time.Second)
+	}
+	status := apiError.NewGenericServerResponse(e.StatusCode, e.Method, schema.GroupResource{}, "", "", seconds, false).ErrStatus
+	status.Message = e.Error()
+	return status
+}
+
+// RetryAfter returns how long the server asks to wait before retrying.
+func (e *HTTPError) RetryAfter() (time.Duration, bool) {
+	return types.RetryAfter(e.Header)
+}
+
+// StatusCode returns the status code of err if it's a HTTPError, or 0.
+func StatusCode(err error) int {
+	var e *HTTPError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound returns true if err is a 404 Not Found response.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict returns true if err is a 409 Conflict response,
+// or a 412 Precondition Failed response of a conditional request.
+func IsConflict(err error) bool {
+	code := StatusCode(err)
+	return code == http.StatusConflict || code == http.StatusPreconditionFailed
+}
+
+// IsPreconditionFailed returns true if err is a 412 Precondition Failed response.
+func IsPreconditionFailed(err error) bool {
+	return StatusCode(err) == http.StatusPreconditionFailed
+}
+
+// IsRateLimited returns true if err is a 429 Too Many Requests response.
+func IsRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
+
+// IsRetryable returns true if err is likely to be transient: a response asking to retry later,
+// a server side failure of the gateway or a network timeout.
+func IsRetryable(err error) bool {
+	switch StatusCode(err) {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 0:
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	var e *HTTPError
+	if errors.As(err, &e) {
+		_, ok := e.RetryAfter()
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHTTPError(t *testing.T) {
+	cases := []struct {
+		name        string
+		status      int
+		contentType string
+		header      http.Header
+		resp        []byte
+		want        *HTTPError
+		message     string
+		reason      metav1.StatusReason
+		is          []func(error) bool
+		isNot       []func(error) bool
+	}{
+		{
+			name:        "plain_not_found",
+			status:      http.StatusNotFound,
+			contentType: "text/plain",
+			resp:        []byte("no such user"),
+			want: &HTTPError{
+				Method:     "GET",
+				StatusCode: http.StatusNotFound,
+				Body:       []byte("no such user"),
+			},
+			message: "the server responded with status 404: no such user",
+			reason:  metav1.StatusReasonNotFound,
+			is:      []func(error) bool{IsNotFound, apiError.IsNotFound},
+			isNot:   []func(error) bool{IsConflict, IsRetryable, apiError.IsConflict},
+		},
+		{
+			name:        "problem_conflict",
+			status:      http.StatusConflict,
+			contentType: "application/problem+json; charset=utf-8",
+			resp:        []byte(`{"type":"/errors/version","title":"Version conflict","detail":"user a has changed","status":409,"version":"2"}`),
+			want: &HTTPError{
+				Method:     "GET",
+				StatusCode: http.StatusConflict,
+				Body:       []byte(`{"type":"/errors/version","title":"Version conflict","detail":"user a has changed","status":409,"version":"2"}`),
+				Problem: &ProblemDetails{
+					Type:       "/errors/version",
+					Title:      "Version conflict",
+					Status:     409,
+					Detail:     "user a has changed",
+					Extensions: map[string]interface{}{"version": "2"},
+				},
+			},
+			message: "Version conflict: user a has changed",
+			reason:  metav1.StatusReasonConflict,
+			is:      []func(error) bool{IsConflict, apiError.IsConflict},
+			isNot:   []func(error) bool{IsNotFound, IsRateLimited},
+		},
+		{
+			name:        "kubernetes_status",
+			status:      http.StatusConflict,
+			contentType: "application/json",
+			resp:        []byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","message":"a already exists","reason":"AlreadyExists","code":409}`),
+			want: &HTTPError{
+				Method:     "GET",
+				StatusCode: http.StatusConflict,
+				Body:       []byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","message":"a already exists","reason":"AlreadyExists","code":409}`),
+				KubeStatus: &metav1.Status{
+					TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+					Status:   metav1.StatusFailure,
+					Message:  "a already exists",
+					Reason:   metav1.StatusReasonAlreadyExists,
+					Code:     409,
+				},
+			},
+			message: "a already exists",
+			reason:  metav1.StatusReasonAlreadyExists,
+			is:      []func(error) bool{IsConflict, apiError.IsAlreadyExists},
+			isNot:   []func(error) bool{apiError.IsConflict},
+		},
+		{
+			name:        "rate_limited",
+			status:      http.StatusTooManyRequests,
+			contentType: "application/json",
+			header:      http.Header{"Retry-After": []string{"x"}},
+			want: &HTTPError{
+				Method:     "GET",
+				StatusCode: http.StatusTooManyRequests,
+				Body:       []byte{},
+			},
+			message: "the server responded with status 429 Too Many Requests",
+			reason:  metav1.StatusReasonTooManyRequests,
+			is:      []func(error) bool{IsRateLimited, IsRetryable, apiError.IsTooManyRequests},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			for k, v := range c.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Type", c.contentType)
+			w.WriteHeader(c.status)
+			w.Write(c.resp)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		_, err = cli.Get(context.TODO(), "/test", nil)
+
+		got, ok := err.(*HTTPError)
+		if !ok {
+			t.Errorf("HTTPError(%q) got error %#v. wanted a HTTPError", c.name, err)
+			continue
+		}
+		got.Header = nil
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("HTTPError(%q) want: %#v\ngot: %#v", c.name, c.want, got)
+		}
+		if got.Error() != c.message {
+			t.Errorf("HTTPError(%q) got message %q. wanted %q", c.name, got.Error(), c.message)
+		}
+		if reason := apiError.ReasonForError(err); reason != c.reason {
+			t.Errorf("HTTPError(%q) got reason %s. wanted %s", c.name, reason, c.reason)
+		}
+		for i, is := range c.is {
+			if !is(err) {
+				t.Errorf("HTTPError(%q) wanted to satisfy helper %d", c.name, i)
+			}
+		}
+		for i, is := range c.isNot {
+			if is(err) {
+				t.Errorf("HTTPError(%q) wanted not to satisfy helper %d", c.name, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/url"
+	"path"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+)
+
+var _ http.Interface = &Client{}
+
+// Client is an in-memory http.Interface serving the objects of its Tracker.
+//
+// GET returns the object at the path, or the list of the collection if there is no
+// such object but some under the path. List, and GET requests telling they list, always
+// return the list, {"items":[]} if the collection is empty.
+// POST creates an object under the collection, at the path of its name.
+// DELETE deletes the object at the path, or the whole collection likewise.
+type Client struct {
+	Fake
+	Tracker *Tracker
+	// Name returns the name of the object to create, the "name" or the "metadata.name"
+	// of the json data by default.
+	Name func(data []byte) (string, error)
+}
+
+// NewClient creates a fake client serving objects, which are keyed by their absPath.
+func NewClient(objects map[string][]byte) *Client {
+	tracker := NewTracker()
+	for absPath, data := range objects {
+		tracker.Add(absPath, data)
+	}
+	return &Client{Tracker: tracker, Name: objectName}
+}
+
+// Do implements http.Interface
+func (c *Client) Do(ctx context.Context, request *http.Request) (*http.Response, error) {
+	action := NewAction(request.Verb, request.AbsPath, request.PatchType, request.Body, &types.Options{Header: url.Values(request.Header)}, request.Option)
+	action.List = request.List
+	return c.Invoke(action, c.react)
+}
+
+// react serves action from the tracker.
+func (c *Client) react(action Action) (bool, *http.Response, error) {
+	var data []byte
+	var err error
+	statusCode := nethttp.StatusOK
+	switch action.Verb {
+	case nethttp.MethodGet:
+		if action.List {
+			data, err = c.Tracker.List(action.AbsPath)
+		} else if data, err = c.Tracker.Get(action.AbsPath); nil != err && c.Tracker.hasItems(action.AbsPath) {
+			data, err = c.Tracker.List(action.AbsPath)
+		}
+	case nethttp.MethodPost:
+		var name string
+		if name, err = c.Name(action.Body); nil == err {
+			data, statusCode = action.Body, nethttp.StatusCreated
+			err = c.Tracker.Create(path.Join(action.AbsPath, name), data)
+		}
+	case nethttp.MethodPut:
+		data = action.Body
+		err = c.Tracker.Update(action.AbsPath, data)
+	case nethttp.MethodPatch:
+		data, err = c.Tracker.Patch(action.AbsPath, action.PatchType, action.Body)
+	case nethttp.MethodDelete:
+		if data, err = c.Tracker.Delete(action.AbsPath); nil != err && c.Tracker.hasItems(action.AbsPath) {
+			data, err = c.Tracker.DeleteCollection(action.AbsPath)
+		}
+	default:
+		err = NewError(action.Verb, nethttp.StatusMethodNotAllowed, action.Verb+" is not supported")
+	}
+	if nil != err {
+		if e, ok := err.(*http.HTTPError); ok {
+			return true, &http.Response{StatusCode: e.StatusCode, Header: e.Header, Body: e.Body}, err
+		}
+		return true, nil, err
+	}
+	return true, &http.Response{StatusCode: statusCode, Header: nethttp.Header{"Content-Type": []string{"application/json"}}, Body: data}, nil
+}
+
+func (c *Client) Get(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodGet, AbsPath: absPath, Option: option}))
+}
+
+func (c *Client) List(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodGet, AbsPath: absPath, Option: option, List: true}))
+}
+
+func (c *Client) Create(ctx context.Context, absPath string, data []byte, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodPost, AbsPath: absPath, Body: data, Option: option}))
+}
+
+func (c *Client) Update(ctx context.Context, absPath string, data []byte, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodPut, AbsPath: absPath, Body: data, Option: option}))
+}
+
+func (c *Client) Patch(ctx context.Context, absPath string, pt types2.PatchType, data []byte, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodPatch, AbsPath: absPath, PatchType: pt, Body: data, Option: option}))
+}
+
+func (c *Client) Delete(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return http.Body(c.Do(ctx, &http.Request{Verb: nethttp.MethodDelete, AbsPath: absPath, Option: option}))
+}
+
+// Watch implements http.Interface, it streams the changes of the collection absPath.
+// If a reactor handles the watch without an error, the watch ends right away.
+func (c *Client) Watch(ctx context.Context, absPath string, option types.Option) (<-chan http.Event, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	var events <-chan http.Event
+	_, err := c.Invoke(NewAction(Watch, absPath, "", nil, option), func(action Action) (bool, *http.Response, error) {
+		events = c.Tracker.Watch(ctx, absPath)
+		return true, nil, nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	if nil == events {
+		ended := make(chan http.Event)
+		close(ended)
+		events = ended
+	}
+	return events, nil
+}
+
+// objectName returns the "name" or the "metadata.name" of data.
+func objectName(data []byte) (string, error) {
+	obj := struct {
+		Name     string `json:"name"`
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(data, &obj); nil != err {
+		return "", NewError(nethttp.MethodPost, nethttp.StatusBadRequest, err.Error())
+	}
+	if len(obj.Metadata.Name) > 0 {
+		return obj.Metadata.Name, nil
+	}
+	if len(obj.Name) > 0 {
+		return obj.Name, nil
+	}
+	return "", NewError(nethttp.MethodPost, nethttp.StatusUnprocessableEntity, "the object has no name")
+}
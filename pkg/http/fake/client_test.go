@@ -0,0 +1,221 @@
+package fake
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.TODO()
+	cli := NewClient(map[string][]byte{"/users/a": []byte(`{"name":"a","age":1}`)})
+
+	cases := []struct {
+		name string
+		do   func() ([]byte, error)
+		want string
+		code int
+	}{
+		{
+			name: "get",
+			do:   func() ([]byte, error) { return cli.Get(ctx, "/users/a", nil) },
+			want: `{"name":"a","age":1}`,
+		},
+		{
+			name: "get_not_found",
+			do:   func() ([]byte, error) { return cli.Get(ctx, "/users/b/x", nil) },
+			want: "/users/b/x not found",
+			code: nethttp.StatusNotFound,
+		},
+		{
+			name: "create",
+			do:   func() ([]byte, error) { return cli.Create(ctx, "/users", []byte(`{"name":"b"}`), nil) },
+			want: `{"name":"b"}`,
+		},
+		{
+			name: "create_exists",
+			do:   func() ([]byte, error) { return cli.Create(ctx, "/users/", []byte(`{"name":"b"}`), nil) },
+			want: "/users/b already exists",
+			code: nethttp.StatusConflict,
+		},
+		{
+			name: "list",
+			do:   func() ([]byte, error) { return cli.List(ctx, "/users", nil) },
+			want: `{"items":[{"name":"a","age":1},{"name":"b"}]}`,
+		},
+		{
+			name: "merge_patch",
+			do: func() ([]byte, error) {
+				return cli.Patch(ctx, "/users/a", types2.MergePatchType, []byte(`{"age":2}`), nil)
+			},
+			want: `{"age":2,"name":"a"}`,
+		},
+		{
+			name: "json_patch",
+			do: func() ([]byte, error) {
+				return cli.Patch(ctx, "/users/b", types2.JSONPatchType, []byte(`[{"op":"add","path":"/age","value":3}]`), nil)
+			},
+			want: `{"age":3,"name":"b"}`,
+		},
+		{
+			name: "update",
+			do:   func() ([]byte, error) { return cli.Update(ctx, "/users/b", []byte(`{"name":"b","age":4}`), nil) },
+			want: `{"name":"b","age":4}`,
+		},
+		{
+			name: "delete",
+			do:   func() ([]byte, error) { return cli.Delete(ctx, "/users/a", nil) },
+			want: `{"age":2,"name":"a"}`,
+		},
+		{
+			name: "list_after_delete",
+			do:   func() ([]byte, error) { return cli.List(ctx, "/users", nil) },
+			want: `{"items":[{"name":"b","age":4}]}`,
+		},
+		{
+			name: "delete_collection",
+			do:   func() ([]byte, error) { return cli.Delete(ctx, "/users", nil) },
+			want: `{"items":[{"name":"b","age":4}]}`,
+		},
+		{
+			name: "get_after_delete_collection",
+			do:   func() ([]byte, error) { return cli.Get(ctx, "/users/b", nil) },
+			want: "/users/b not found",
+			code: nethttp.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		bt, err := c.do()
+		if http.StatusCode(err) != c.code {
+			t.Errorf("Client(%q) got error %v. wanted status %d", c.name, err, c.code)
+		}
+		if string(bt) != c.want {
+			t.Errorf("Client(%q) got %s. wanted %s", c.name, bt, c.want)
+		}
+	}
+}
+
+func TestClientReactors(t *testing.T) {
+	ctx := context.TODO()
+	cli := NewClient(nil)
+	injected := errors.New("injected")
+	cli.AddReactor(nethttp.MethodGet, "/users/a", ErrorReaction(injected))
+	cli.PrependReactor("*", "*", func(action Action) (bool, *http.Response, error) {
+		if action.Query.Get("dryRun") == "All" {
+			return true, &http.Response{StatusCode: nethttp.StatusOK, Body: []byte("dry")}, nil
+		}
+		return false, nil, nil
+	})
+
+	if _, err := cli.Get(ctx, "/users/a", nil); err != injected {
+		t.Errorf("Get got error %v. wanted %v", err, injected)
+	}
+	option := &types.Options{Params: types.QueryParameters{"dryRun": "All"}, Header: url.Values{"X-Trace": []string{"1"}}}
+	if bt, err := cli.Create(ctx, "/users", []byte(`{"name":"a"}`), option); nil != err || string(bt) != "dry" {
+		t.Errorf("Create got %s, %v. wanted the reaction", bt, err)
+	}
+	if cli.Tracker.Has("/users/a") {
+		t.Errorf("Create got /users/a created. wanted it handled by the reactor")
+	}
+
+	actions := cli.Actions()
+	if len(actions) != 2 {
+		t.Fatalf("Actions got %d actions. wanted 2", len(actions))
+	}
+	want := Action{Verb: nethttp.MethodPost, AbsPath: "/users", Body: []byte(`{"name":"a"}`), Query: url.Values{"dryRun": []string{"All"}}}
+	got := actions[1]
+	if got.Header.Get("X-Trace") != "1" {
+		t.Errorf("Actions got header %v. wanted X-Trace", got.Header)
+	}
+	got.Header = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Actions want: %#v\ngot: %#v", want, got)
+	}
+	cli.ClearActions()
+	if len(cli.Actions()) != 0 {
+		t.Errorf("ClearActions got %d actions left", len(cli.Actions()))
+	}
+}
+
+func TestClientWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cli := NewClient(nil)
+	events, err := cli.Watch(ctx, "/users", nil)
+	if nil != err {
+		t.Fatalf("unexpected error when watching: %v", err)
+	}
+	cli.Create(ctx, "/users", []byte(`{"name":"a"}`), nil)
+	cli.Patch(ctx, "/users/a", types2.MergePatchType, []byte(`{"age":1}`), nil)
+	cli.Delete(ctx, "/users/a", nil)
+	cli.Create(ctx, "/groups", []byte(`{"name":"a"}`), nil)
+
+	want := []http.Event{
+		{Type: http.Added, Object: []byte(`{"name":"a"}`)},
+		{Type: http.Modified, Object: []byte(`{"age":1,"name":"a"}`)},
+		{Type: http.Deleted, Object: []byte(`{"age":1,"name":"a"}`)},
+	}
+	for i := range want {
+		if got := <-events; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("Watch got event %d %s %s. wanted %s %s", i, got.Type, got.Object, want[i].Type, want[i].Object)
+		}
+	}
+	cancel()
+	if e, ok := <-events; ok {
+		t.Errorf("Watch got event %s %s. wanted closed", e.Type, e.Object)
+	}
+}
+
+func TestClientWatchSlowReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cli := NewClient(nil)
+	events, err := cli.Watch(ctx, "/users", nil)
+	if nil != err {
+		t.Fatalf("unexpected error when watching: %v", err)
+	}
+
+	// a watch which isn't read doesn't block the changes, nor the reads
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cli.Create(ctx, "/users", []byte(`{"name":"a"}`), nil)
+		for i := 1; i < 200; i++ {
+			cli.Update(ctx, "/users/a", []byte(`{"name":"a"}`), nil)
+			cli.Get(ctx, "/users/a", nil)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Update blocked by a watch")
+	}
+	for i := 0; i < 200; i++ {
+		if e := <-events; e.Type != http.Added && e.Type != http.Modified {
+			t.Fatalf("Watch got event %d %s. wanted added or modified", i, e.Type)
+		}
+	}
+}
+
+func TestClientWatchReactor(t *testing.T) {
+	cli := NewClient(nil)
+	cli.AddReactor(Watch, "*", func(action Action) (bool, *http.Response, error) {
+		return true, nil, nil
+	})
+	events, err := cli.Watch(context.TODO(), "/users", nil)
+	if nil != err || nil == events {
+		t.Fatalf("Watch got %v, %v. wanted a channel", events, err)
+	}
+	if e, ok := <-events; ok {
+		t.Errorf("Watch got event %s %s. wanted closed", e.Type, e.Object)
+	}
+}
@@ -0,0 +1,130 @@
+// Package fake provides an in-memory http.Interface for unit tests.
+package fake
+
+import (
+	nethttp "net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+)
+
+// Watch is the verb of the actions of watch requests.
+const Watch = "WATCH"
+
+// Action is a request received by a fake client.
+type Action struct {
+	Verb      string
+	AbsPath   string
+	PatchType types2.PatchType
+	Body      []byte
+	// List tells that a GET lists the collection at AbsPath, see http.Request.
+	List bool
+	// Query and Header are those set by the options of the request.
+	Query  url.Values
+	Header nethttp.Header
+}
+
+// NewAction creates the action of a request, options are applied in order
+// like they are to a real request.
+func NewAction(verb, absPath string, pt types2.PatchType, body []byte, options ...types.Option) Action {
+	action := Action{Verb: verb, AbsPath: absPath, PatchType: pt, Body: body}
+	if req, err := http.CaptureRequest(verb, absPath, pt, body, options...); nil == err {
+		action.Query = req.URL.Query()
+		action.Header = req.Header
+	}
+	return action
+}
+
+// ReactionFunc reacts to an action. If handled is false the action is passed to the
+// next reactor, otherwise resp and err are returned as the result of the request.
+type ReactionFunc func(action Action) (handled bool, resp *http.Response, err error)
+
+type reactor struct {
+	verb     string
+	absPath  string
+	reaction ReactionFunc
+}
+
+func (r *reactor) handles(action Action) bool {
+	return (r.verb == "*" || r.verb == action.Verb) && (r.absPath == "*" || r.absPath == action.AbsPath)
+}
+
+// Fake records actions and invokes reactors for them.
+type Fake struct {
+	lock     sync.RWMutex
+	actions  []Action
+	reactors []reactor
+}
+
+// AddReactor appends a reactor for the actions with verb and absPath, "*" matches any.
+func (f *Fake) AddReactor(verb, absPath string, reaction ReactionFunc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reactors = append(f.reactors, reactor{verb, absPath, reaction})
+}
+
+// PrependReactor adds a reactor which is invoked before all the others.
+func (f *Fake) PrependReactor(verb, absPath string, reaction ReactionFunc) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.reactors = append([]reactor{{verb, absPath, reaction}}, f.reactors...)
+}
+
+// Invoke records action and invokes the reactors for it, defaultReaction is invoked
+// if none of them handled it.
+func (f *Fake) Invoke(action Action, defaultReaction ReactionFunc) (*http.Response, error) {
+	f.lock.Lock()
+	f.actions = append(f.actions, action)
+	reactors := f.reactors
+	f.lock.Unlock()
+
+	for i := range reactors {
+		if !reactors[i].handles(action) {
+			continue
+		}
+		if handled, resp, err := reactors[i].reaction(action); handled {
+			return resp, err
+		}
+	}
+	_, resp, err := defaultReaction(action)
+	return resp, err
+}
+
+// Actions returns the actions recorded so far.
+func (f *Fake) Actions() []Action {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return append([]Action(nil), f.actions...)
+}
+
+// ClearActions clears the actions recorded so far.
+func (f *Fake) ClearActions() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.actions = nil
+}
+
+// ErrorReaction handles actions by failing them with err.
+func ErrorReaction(err error) ReactionFunc {
+	return func(action Action) (bool, *http.Response, error) {
+		return true, nil, err
+	}
+}
+
+// DelayReaction delays actions by d and passes them to the next reactor.
+func DelayReaction(d time.Duration) ReactionFunc {
+	return func(action Action) (bool, *http.Response, error) {
+		time.Sleep(d)
+		return false, nil, nil
+	}
+}
+
+// NewError creates the error of a request responded with statusCode.
+func NewError(verb string, statusCode int, message string) error {
+	return &http.HTTPError{Method: verb, StatusCode: statusCode, Header: nethttp.Header{}, Body: []byte(message)}
+}
@@ -0,0 +1,263 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	nethttp "net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alauda/kube-rest/pkg/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	types2 "k8s.io/apimachinery/pkg/types"
+)
+
+// Tracker keeps the json data of objects in memory, keyed by their absPath.
+//
+// The objects directly under an absPath are its collection, they are listed as
+// {"items": [...]} and watched by the absPath.
+type Tracker struct {
+	lock     sync.RWMutex
+	objects  map[string][]byte
+	watchers map[string][]*watcher
+}
+
+// watcher buffers the events of a watch, so that notifying it never blocks.
+type watcher struct {
+	lock    sync.Mutex
+	pending []http.Event
+	ready   chan struct{}
+}
+
+// push queues event.
+func (w *watcher) push(event http.Event) {
+	w.lock.Lock()
+	w.pending = append(w.pending, event)
+	w.lock.Unlock()
+	select {
+	case w.ready <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the queued events to events in order until ctx is done, then closes it.
+func (w *watcher) run(ctx context.Context, events chan<- http.Event) {
+	defer close(events)
+	for {
+		w.lock.Lock()
+		pending := w.pending
+		w.pending = nil
+		w.lock.Unlock()
+		for _, event := range pending {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-w.ready:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// NewTracker creates an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{objects: map[string][]byte{}, watchers: map[string][]*watcher{}}
+}
+
+// Add puts the object at absPath, replacing the existing one if any, without any event.
+func (t *Tracker) Add(absPath string, data []byte) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.objects[clean(absPath)] = data
+}
+
+// Get returns the object at absPath.
+func (t *Tracker) Get(absPath string) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	data, ok := t.objects[clean(absPath)]
+	if !ok {
+		return nil, notFound(nethttp.MethodGet, absPath)
+	}
+	return data, nil
+}
+
+// List returns the objects of the collection absPath, ordered by their paths.
+func (t *Tracker) List(absPath string) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	paths := t.items(absPath)
+	sort.Strings(paths)
+	items := make([]json.RawMessage, 0, len(paths))
+	for _, p := range paths {
+		items = append(items, t.objects[p])
+	}
+	return json.Marshal(map[string]interface{}{"items": items})
+}
+
+// Has returns whether there is an object at absPath.
+func (t *Tracker) Has(absPath string) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	_, ok := t.objects[clean(absPath)]
+	return ok
+}
+
+// hasItems returns whether the collection absPath has any object.
+func (t *Tracker) hasItems(absPath string) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return len(t.items(absPath)) > 0
+}
+
+// items returns the paths of the objects of the collection absPath.
+func (t *Tracker) items(absPath string) []string {
+	dir := clean(absPath)
+	paths := []string{}
+	for p := range t.objects {
+		if path.Dir(p) == dir && p != dir {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Create puts a new object at absPath.
+func (t *Tracker) Create(absPath string, data []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := clean(absPath)
+	if _, ok := t.objects[p]; ok {
+		return NewError(nethttp.MethodPost, nethttp.StatusConflict, fmt.Sprintf("%s already exists", absPath))
+	}
+	t.objects[p] = data
+	t.notify(p, http.Added, data)
+	return nil
+}
+
+// Update replaces the object at absPath.
+func (t *Tracker) Update(absPath string, data []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := clean(absPath)
+	if _, ok := t.objects[p]; !ok {
+		return notFound(nethttp.MethodPut, absPath)
+	}
+	t.objects[p] = data
+	t.notify(p, http.Modified, data)
+	return nil
+}
+
+// Patch patches the object at absPath and returns the patched object.
+//
+// Json patches are applied as RFC 6902 patches, the others as RFC 7386 merge patches.
+// An apply patch creates the object if it's not found.
+func (t *Tracker) Patch(absPath string, pt types2.PatchType, patch []byte) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := clean(absPath)
+	original, ok := t.objects[p]
+	if !ok {
+		if pt != types2.ApplyPatchType {
+			return nil, notFound(nethttp.MethodPatch, absPath)
+		}
+		t.objects[p] = patch
+		t.notify(p, http.Added, patch)
+		return patch, nil
+	}
+	var data []byte
+	var err error
+	if pt == types2.JSONPatchType {
+		var jsonPatch jsonpatch.Patch
+		if jsonPatch, err = jsonpatch.DecodePatch(patch); nil == err {
+			data, err = jsonPatch.Apply(original)
+		}
+	} else {
+		data, err = jsonpatch.MergePatch(original, patch)
+	}
+	if nil != err {
+		return nil, NewError(nethttp.MethodPatch, nethttp.StatusUnprocessableEntity, err.Error())
+	}
+	t.objects[p] = data
+	t.notify(p, http.Modified, data)
+	return data, nil
+}
+
+// Delete removes the object at absPath and returns it.
+func (t *Tracker) Delete(absPath string) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	p := clean(absPath)
+	data, ok := t.objects[p]
+	if !ok {
+		return nil, notFound(nethttp.MethodDelete, absPath)
+	}
+	delete(t.objects, p)
+	t.notify(p, http.Deleted, data)
+	return data, nil
+}
+
+// DeleteCollection removes the objects of the collection absPath and returns them as a list.
+func (t *Tracker) DeleteCollection(absPath string) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	paths := t.items(absPath)
+	sort.Strings(paths)
+	items := make([]json.RawMessage, 0, len(paths))
+	for _, p := range paths {
+		data := t.objects[p]
+		items = append(items, data)
+		delete(t.objects, p)
+		t.notify(p, http.Deleted, data)
+	}
+	return json.Marshal(map[string]interface{}{"items": items})
+}
+
+// Watch returns the events of the collection absPath until ctx is done.
+func (t *Tracker) Watch(ctx context.Context, absPath string) <-chan http.Event {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	dir := clean(absPath)
+	w := &watcher{ready: make(chan struct{}, 1)}
+	t.watchers[dir] = append(t.watchers[dir], w)
+	events := make(chan http.Event)
+	go w.run(ctx, events)
+	go func() {
+		<-ctx.Done()
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		watchers := t.watchers[dir]
+		for i := range watchers {
+			if watchers[i] == w {
+				t.watchers[dir] = append(watchers[:i:i], watchers[i+1:]...)
+				break
+			}
+		}
+	}()
+	return events
+}
+
+// notify queues the event of the object at p to the watchers of its collection, without
+// waiting for them to receive it.
+func (t *Tracker) notify(p string, eventType http.EventType, data []byte) {
+	for _, w := range t.watchers[path.Dir(p)] {
+		w.push(http.Event{Type: eventType, Object: bytes.TrimSpace(data)})
+	}
+}
+
+func clean(absPath string) string {
+	return path.Clean("/" + strings.TrimSuffix(absPath, "/"))
+}
+
+func notFound(verb, absPath string) error {
+	return NewError(verb, nethttp.StatusNotFound, fmt.Sprintf("%s not found", absPath))
+}
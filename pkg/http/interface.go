@@ -10,10 +10,15 @@ import (
 
 // Interface for http requests
 type Interface interface {
-	Get(ctx context.Context, absPath string) ([]byte, error)
+	// Do makes request and returns its response with the metadata.
+	Do(ctx context.Context, request *Request) (*Response, error)
+	Get(ctx context.Context, absPath string, option types.Option) ([]byte, error)
 	List(ctx context.Context, absPath string, option types.Option) ([]byte, error)
 	Create(ctx context.Context, absPath string, data []byte, option types.Option) ([]byte, error)
 	Update(ctx context.Context, absPath string, data []byte, option types.Option) ([]byte, error)
-	Patch(ctx context.Context, absPath string, pt types2.PatchType, data []byte) ([]byte, error)
+	Patch(ctx context.Context, absPath string, pt types2.PatchType, data []byte, option types.Option) ([]byte, error)
 	Delete(ctx context.Context, absPath string, option types.Option) ([]byte, error)
+	// Watch streams the events of absPath until ctx is done or the server ends the response,
+	// the returned channel is closed then.
+	Watch(ctx context.Context, absPath string, option types.Option) (<-chan Event, error)
 }
@@ -0,0 +1,82 @@
+package recorder
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Request is a recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  url.Values  `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and the response the server responded to it.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is a file of recorded interactions, it's YAML unless the file name ends with ".json".
+type Cassette struct {
+	// Path is the file of the cassette.
+	Path         string         `json:"-"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Load loads the cassette from file.
+func Load(file string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(file)
+	if nil != err {
+		return nil, err
+	}
+	cassette := &Cassette{Path: file}
+	if isJSON(file) {
+		err = json.Unmarshal(data, cassette)
+	} else {
+		err = yaml.Unmarshal(data, cassette)
+	}
+	if nil != err {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette into its file, creating the directories of it if necessary.
+func (c *Cassette) Save() error {
+	var data []byte
+	var err error
+	if isJSON(c.Path) {
+		data, err = json.MarshalIndent(c, "", "  ")
+	} else {
+		data, err = yaml.Marshal(c)
+	}
+	if nil != err {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0755); nil != err {
+		return err
+	}
+	return ioutil.WriteFile(c.Path, data, 0644)
+}
+
+func isJSON(file string) bool {
+	return strings.EqualFold(filepath.Ext(file), ".json")
+}
@@ -0,0 +1,70 @@
+package recorder
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+)
+
+// Matcher decides whether a recorded request matches req, whose body is body.
+type Matcher func(req *http.Request, body string, recorded *Request) bool
+
+// MatchMethod matches requests of the same method.
+func MatchMethod(req *http.Request, body string, recorded *Request) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchPath matches requests of the same path.
+func MatchPath(req *http.Request, body string, recorded *Request) bool {
+	return req.URL.Path == recorded.Path
+}
+
+// MatchQuery matches requests of the same query parameters, regardless of their order.
+func MatchQuery(req *http.Request, body string, recorded *Request) bool {
+	query := req.URL.Query()
+	if len(query) == 0 && len(recorded.Query) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(query, recorded.Query)
+}
+
+// MatchBody matches requests of the same body.
+func MatchBody(req *http.Request, body string, recorded *Request) bool {
+	return body == recorded.Body
+}
+
+// MatchHeader matches requests of the same values of the headers keys.
+func MatchHeader(keys ...string) Matcher {
+	return func(req *http.Request, body string, recorded *Request) bool {
+		for _, key := range keys {
+			if !reflect.DeepEqual(req.Header.Values(key), recorded.Header.Values(key)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// DefaultMatchers match requests by their method, path and query parameters.
+var DefaultMatchers = []Matcher{MatchMethod, MatchPath, MatchQuery}
+
+// Rule uses Matchers for the requests of Method and Path.
+type Rule struct {
+	// Method is the method of the requests, any method if empty.
+	Method string
+	// Path is the pattern of the paths of the requests as path.Match, any path if empty.
+	Path     string
+	Matchers []Matcher
+}
+
+func (r *Rule) applies(req *http.Request) bool {
+	if len(r.Method) > 0 && r.Method != req.Method {
+		return false
+	}
+	if len(r.Path) > 0 {
+		if ok, _ := path.Match(r.Path, req.URL.Path); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,213 @@
+// Package recorder records the requests made with a rest.Config into cassettes
+// and replays them offline, for tests.
+package recorder
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/rest"
+)
+
+// Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// ModeReplay replays the interactions of the cassette, a request that isn't recorded fails.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the server and records them into the cassette.
+	ModeRecord
+	// ModeAuto replays the cassette if its file exists, or records it otherwise.
+	ModeAuto
+)
+
+// Redacted replaces the values of redacted headers.
+const Redacted = "REDACTED"
+
+// DefaultRedactedHeaders are the headers carrying secrets.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Recorder is a transport recording or replaying requests. Responses are read
+// entirely before they are recorded, so streams like watches are recorded once they end.
+type Recorder struct {
+	// Mode is ModeRecord or ModeReplay, ModeAuto is resolved by New.
+	Mode     Mode
+	Cassette *Cassette
+	// Matchers match the requests for which no rule applies, DefaultMatchers if empty.
+	Matchers []Matcher
+	// Rules are the matchers of specific requests, the first one applies.
+	Rules []Rule
+	// RedactedHeaders are the headers whose values are not recorded.
+	RedactedHeaders []string
+
+	lock sync.Mutex
+	used map[*Interaction]bool
+}
+
+// New creates a recorder with the cassette file. In ModeReplay the cassette must exist,
+// in ModeRecord it's replaced when the recorder is stopped.
+func New(file string, mode Mode) (*Recorder, error) {
+	if mode == ModeAuto {
+		mode = ModeRecord
+		if _, err := os.Stat(file); nil == err {
+			mode = ModeReplay
+		}
+	}
+	cassette := &Cassette{Path: file}
+	if mode == ModeReplay {
+		var err error
+		if cassette, err = Load(file); nil != err {
+			return nil, err
+		}
+	}
+	return &Recorder{
+		Mode:            mode,
+		Cassette:        cassette,
+		RedactedHeaders: DefaultRedactedHeaders,
+		used:            map[*Interaction]bool{},
+	}, nil
+}
+
+// Config returns a copy of cfg whose requests go through the recorder.
+func (r *Recorder) Config(cfg *rest.Config) *rest.Config {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Wrap(r.Wrap)
+	return cfg
+}
+
+// Wrap wraps the transport rt with the recorder, rt isn't used in ModeReplay.
+func (r *Recorder) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &transport{recorder: r, rt: rt}
+}
+
+// Stop saves the cassette if it's recording.
+func (r *Recorder) Stop() error {
+	if r.Mode != ModeRecord {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.Cassette.Save()
+}
+
+// record appends the interaction of req and resp into the cassette.
+func (r *Recorder) record(req *http.Request, body []byte, resp *http.Response, respBody []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Cassette.Interactions = append(r.Cassette.Interactions, &Interaction{
+		Request: Request{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query(),
+			Header: r.redact(req.Header),
+			Body:   string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redact(resp.Header),
+			Body:       string(respBody),
+		},
+	})
+}
+
+// replay returns the first unused interaction matching req.
+func (r *Recorder) replay(req *http.Request, body []byte) (*Interaction, error) {
+	matchers := r.matchers(req)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, interaction := range r.Cassette.Interactions {
+		if r.used[interaction] || !matches(matchers, req, string(body), &interaction.Request) {
+			continue
+		}
+		r.used[interaction] = true
+		return interaction, nil
+	}
+	return nil, fmt.Errorf("no interaction recorded in %s for %s %s", r.Cassette.Path, req.Method, req.URL)
+}
+
+func (r *Recorder) matchers(req *http.Request) []Matcher {
+	for i := range r.Rules {
+		if r.Rules[i].applies(req) {
+			return r.Rules[i].Matchers
+		}
+	}
+	if len(r.Matchers) > 0 {
+		return r.Matchers
+	}
+	return DefaultMatchers
+}
+
+func matches(matchers []Matcher, req *http.Request, body string, recorded *Request) bool {
+	for _, match := range matchers {
+		if !match(req, body, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+// redact returns a copy of header with the values of the redacted headers replaced.
+func (r *Recorder) redact(header http.Header) http.Header {
+	header = header.Clone()
+	for key := range header {
+		for _, redacted := range r.RedactedHeaders {
+			if strings.EqualFold(key, redacted) {
+				header[key] = []string{Redacted}
+			}
+		}
+	}
+	return header
+}
+
+type transport struct {
+	recorder *Recorder
+	rt       http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if nil != req.Body {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); nil != err {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	if t.recorder.Mode == ModeReplay {
+		interaction, err := t.recorder.replay(req, body)
+		if nil != err {
+			return nil, err
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.rt.RoundTrip(req)
+	if nil != err {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if nil != err {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	t.recorder.record(req, body, resp, respBody)
+	return resp, nil
+}
@@ -0,0 +1,99 @@
+package recorder
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alauda/kube-rest/pkg/config"
+	http2 "github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+func TestRecorder(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+	}{
+		{name: "yaml", file: "cassette.yaml"},
+		{name: "json", file: "cassette.json"},
+	}
+
+	option := &types.Options{
+		Header: url.Values{"Authorization": []string{"Bearer secret"}, "Content-Type": []string{"application/json"}},
+		Params: types.QueryParameters{"dryRun": "All"},
+	}
+	for _, c := range cases {
+		file := filepath.Join(t.TempDir(), c.file)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bt, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Set-Cookie", "session=secret")
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			w.Write([]byte(r.Method + " " + r.URL.RawQuery + " " + string(bt)))
+		}))
+		cfg, _ := config.GetDefaultConfig(srv.URL)
+
+		do := func(mode Mode) ([]string, error) {
+			rec, err := New(file, mode)
+			if nil != err {
+				return nil, err
+			}
+			rec.Rules = []Rule{{Method: "POST", Matchers: []Matcher{MatchMethod, MatchPath, MatchBody}}}
+			cli, err := http2.NewForConfig(rec.Config(cfg))
+			if nil != err {
+				return nil, err
+			}
+			got := []string{}
+			for _, body := range []string{`{"a":1}`, `{"a":2}`} {
+				bt, err := cli.Create(context.TODO(), "/users", []byte(body), option)
+				if nil != err {
+					return nil, err
+				}
+				got = append(got, string(bt))
+			}
+			bt, err := cli.Get(context.TODO(), "/users", option)
+			if nil != err {
+				return nil, err
+			}
+			got = append(got, string(bt))
+			if _, err := cli.Get(context.TODO(), "/missing", nil); !http2.IsNotFound(err) {
+				t.Errorf("Recorder(%q) got error %v. wanted not found", c.name, err)
+			}
+			return got, rec.Stop()
+		}
+
+		recorded, err := do(ModeAuto)
+		srv.Close()
+		if nil != err {
+			t.Errorf("Recorder(%q) got error when recording: %v", c.name, err)
+			continue
+		}
+		data, _ := ioutil.ReadFile(file)
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("Recorder(%q) got secrets recorded:\n%s", c.name, data)
+		}
+
+		replayed, err := do(ModeAuto)
+		if nil != err {
+			t.Errorf("Recorder(%q) got error when replaying: %v", c.name, err)
+			continue
+		}
+		if strings.Join(recorded, "\n") != strings.Join(replayed, "\n") {
+			t.Errorf("Recorder(%q) want: %v\ngot: %v", c.name, recorded, replayed)
+		}
+
+		rec, _ := New(file, ModeReplay)
+		cli, _ := http2.NewForConfig(rec.Config(cfg))
+		if _, err := cli.Get(context.TODO(), "/users", nil); nil == err {
+			t.Errorf("Recorder(%q) got an unrecorded request replayed", c.name)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/net"
+)
+
+// Request describes a request made with Interface.Do.
+type Request struct {
+	// Verb is the http method of the request, e.g. http.MethodGet.
+	Verb    string
+	AbsPath string
+	// PatchType is the content type of a PATCH request.
+	PatchType types2.PatchType
+	Body      []byte
+	// Header is set before the default option of the client and the option of the request,
+	// which override it, e.g. the content types negotiated by a rest client.
+	Header http.Header
+	Option types.Option
+	// List tells that a GET lists the collection at AbsPath, for servers which can't
+	// tell it from the path, like fake ones. Real servers ignore it.
+	List bool
+}
+
+// Response holds the body and the metadata of a http response.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Duration is the time taken by the request, including its retries.
+	Duration time.Duration
+
+	// errorBody is the body of a non-2xx response, which is not always returned by client-go.
+	errorBody []byte
+}
+
+// Body returns the body of resp along with err, the results of Interface.Do, nil if there
+// is no response.
+func Body(resp *Response, err error) ([]byte, error) {
+	if nil == resp {
+		return nil, err
+	}
+	return resp.Body, err
+}
+
+// Location returns the url of the Location header, relative to the request path,
+// e.g. the url of the object created by a 201 response.
+func (r *Response) Location(absPath string) (*url.URL, error) {
+	location := r.Header.Get("Location")
+	if len(location) == 0 {
+		return nil, errors.New("no Location in response")
+	}
+	ref, err := url.Parse(location)
+	if nil != err {
+		return nil, err
+	}
+	return (&url.URL{Path: absPath}).ResolveReference(ref), nil
+}
+
+type responseKey struct{}
+
+// WithResponse returns a copy of ctx which records the status code and the header
+// of the response of a request made with it into resp, as well as into those recorded by ctx.
+func WithResponse(ctx context.Context, resp *Response) context.Context {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	recorded, _ := ctx.Value(responseKey{}).([]*Response)
+	return context.WithValue(ctx, responseKey{}, append(recorded[:len(recorded):len(recorded)], resp))
+}
+
+// recordResponse records the status code and the header of resp into those recorded by ctx,
+// for responses not received by the transport.
+func recordResponse(ctx context.Context, resp *Response) {
+	recorded, _ := ctx.Value(responseKey{}).([]*Response)
+	for _, r := range recorded {
+		r.StatusCode = resp.StatusCode
+		r.Header = resp.Header.Clone()
+	}
+}
+
+// isErrorStatus returns whether client-go treats code as an error.
+func isErrorStatus(code int) bool {
+	return code < http.StatusOK || code > http.StatusPartialContent
+}
+
+// responseRecorder records the metadata of responses into the Response carried
+// by the request context.
+type responseRecorder struct {
+	rt http.RoundTripper
+	// noRetry keeps client-go from retrying the requests, the retry policy does, by
+	// hiding the Retry-After header and the connection resets it retries.
+	noRetry bool
+}
+
+// connectionResetError is a connection reset client-go doesn't tell, to not retry it.
+type connectionResetError struct {
+	err error
+}
+
+func (e *connectionResetError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionResetError) Unwrap() error {
+	return e.err
+}
+
+func (r *responseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.rt.RoundTrip(req)
+	if nil != err && r.noRetry && net.IsConnectionReset(err) {
+		err = &connectionResetError{err: err}
+	}
+	if nil == err {
+		recorded, _ := req.Context().Value(responseKey{}).([]*Response)
+		var errorBody []byte
+		if len(recorded) > 0 && isErrorStatus(resp.StatusCode) {
+			errorBody, _ = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(errorBody))
+		}
+		for _, r := range recorded {
+			r.StatusCode = resp.StatusCode
+			r.Header = resp.Header.Clone()
+			r.errorBody = errorBody
+		}
+		if r.noRetry && len(resp.Header.Get("Retry-After")) > 0 {
+			resp.Header = resp.Header.Clone()
+			resp.Header.Del("Retry-After")
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,14 @@
+package http
+
+import (
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+// RetryPolicy decides whether and when a failed request is retried, see types.RetryPolicy.
+type RetryPolicy = types.RetryPolicy
+
+// DefaultRetryPolicy returns a policy retrying throttled and unavailable requests
+// up to 3 attempts with exponential back-off.
+func DefaultRetryPolicy() *RetryPolicy {
+	return types.DefaultRetryPolicy()
+}
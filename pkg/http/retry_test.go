@@ -0,0 +1,211 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/config"
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+func TestRetry(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		Multiplier:           2,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}
+	cases := []struct {
+		name     string
+		status   int
+		failures int
+		do       func(cli Interface) error
+		wantErr  bool
+		attempts int
+	}{
+		{
+			name:     "get_retried",
+			status:   http.StatusServiceUnavailable,
+			failures: 2,
+			do: func(cli Interface) error {
+				_, err := cli.Get(context.TODO(), "/test", nil)
+				return err
+			},
+			attempts: 3,
+		},
+		{
+			name:     "get_exhausted",
+			status:   http.StatusServiceUnavailable,
+			failures: 3,
+			do: func(cli Interface) error {
+				_, err := cli.Get(context.TODO(), "/test", nil)
+				return err
+			},
+			wantErr:  true,
+			attempts: 3,
+		},
+		{
+			name:     "get_not_retryable",
+			status:   http.StatusNotFound,
+			failures: 1,
+			do: func(cli Interface) error {
+				_, err := cli.Get(context.TODO(), "/test", nil)
+				return err
+			},
+			wantErr:  true,
+			attempts: 1,
+		},
+		{
+			name:     "create_not_idempotent",
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			do: func(cli Interface) error {
+				_, err := cli.Create(context.TODO(), "/test", nil, defaultOptions)
+				return err
+			},
+			wantErr:  true,
+			attempts: 1,
+		},
+		{
+			name:     "create_idempotent",
+			status:   http.StatusServiceUnavailable,
+			failures: 1,
+			do: func(cli Interface) error {
+				_, err := cli.Create(context.TODO(), "/test", nil, &types.Options{Idempotent: true})
+				return err
+			},
+			attempts: 2,
+		},
+	}
+
+	for _, c := range cases {
+		attempts := 0
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.Header().Set("Content-Type", "application/json")
+			if attempts <= c.failures {
+				w.WriteHeader(c.status)
+			}
+			w.Write([]byte(`{}`))
+		}))
+		defer svr.Close()
+
+		cfg, err := config.GetDefaultConfig(svr.URL)
+		if nil != err {
+			t.Errorf("unexpected error when creating config: %v", err)
+			continue
+		}
+		cli, err := NewForConfig(cfg, WithRetryPolicy(policy))
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		err = c.do(cli)
+		if c.wantErr != (nil != err) {
+			t.Errorf("Retry(%q) got error %v. wanted error: %v", c.name, err, c.wantErr)
+		}
+		if attempts != c.attempts {
+			t.Errorf("Retry(%q) got %d attempts. wanted %d", c.name, attempts, c.attempts)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:          4,
+		InitialBackoff:       time.Second,
+		MaxBackoff:           3 * time.Second,
+		Multiplier:           2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+		HonorRetryAfter:      true,
+	}
+	cases := []struct {
+		name    string
+		attempt int
+		resp    *Response
+		want    time.Duration
+		retry   bool
+	}{
+		{
+			name:    "no_response",
+			attempt: 1,
+			resp:    &Response{},
+			want:    time.Second,
+			retry:   true,
+		},
+		{
+			name:    "exponential",
+			attempt: 2,
+			resp:    &Response{StatusCode: http.StatusTooManyRequests},
+			want:    2 * time.Second,
+			retry:   true,
+		},
+		{
+			name:    "capped",
+			attempt: 3,
+			resp:    &Response{StatusCode: http.StatusTooManyRequests},
+			want:    3 * time.Second,
+			retry:   true,
+		},
+		{
+			name:    "retry_after",
+			attempt: 1,
+			resp:    &Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"10"}}},
+			want:    10 * time.Second,
+			retry:   true,
+		},
+		{
+			name:    "max_attempts",
+			attempt: 4,
+			resp:    &Response{StatusCode: http.StatusTooManyRequests},
+		},
+		{
+			name:    "not_retryable",
+			attempt: 1,
+			resp:    &Response{StatusCode: http.StatusBadRequest},
+		},
+	}
+
+	for _, c := range cases {
+		got, retry := policy.Backoff(c.attempt, c.resp.StatusCode, c.resp.Header)
+		if got != c.want || retry != c.retry {
+			t.Errorf("Backoff(%q) got %v, %v. wanted %v, %v", c.name, got, retry, c.want, c.retry)
+		}
+	}
+}
+
+func TestRetryPolicyReplacesClientGo(t *testing.T) {
+	attempts := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer svr.Close()
+	cfg, err := config.GetDefaultConfig(svr.URL)
+	if nil != err {
+		t.Fatalf("unexpected error when creating config: %v", err)
+	}
+	// the policy doesn't retry, nor does client-go despite the Retry-After header
+	cli, err := NewForClientConfig(&config.ClientConfig{Config: cfg, RetryPolicy: &RetryPolicy{MaxAttempts: 1}})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+
+	_, err = cli.Get(context.TODO(), "/test", nil)
+	if attempts != 1 {
+		t.Errorf("RetryPolicy got %d attempts. wanted 1", attempts)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("RetryPolicy got error %v. wanted a HTTPError", err)
+	}
+	if after, ok := httpErr.RetryAfter(); !ok || after != time.Second {
+		t.Errorf("RetryPolicy got Retry-After %v, %v. wanted 1s", after, ok)
+	}
+}
@@ -3,7 +3,10 @@ package http
 import (
 	"context"
 	"errors"
+	"net/http"
+	"time"
 
+	"github.com/alauda/kube-rest/pkg/config"
 	"github.com/alauda/kube-rest/pkg/types"
 
 	types2 "k8s.io/apimachinery/pkg/types"
@@ -12,79 +15,188 @@ import (
 
 type httpClient struct {
 	Client *rest.RESTClient
+	Retry  *RetryPolicy
+	// Default is applied to every request before its own option.
+	Default     types.Option
+	Middlewares []Middleware
+}
+
+// Handler makes a request, like Interface.Do.
+type Handler func(ctx context.Context, request *Request) (*Response, error)
+
+// Middleware wraps the Handler making the requests of a client, e.g. to inject a header
+// into every request or to log them. A middleware changing the request should pass a copy
+// of it to next.
+type Middleware func(next Handler) Handler
+
+// ClientOption configures the http client created by NewForConfig.
+type ClientOption func(*httpClient)
+
+// WithDefaultOption applies option to every request, the option of a request replaces
+// its headers and parameters of the same keys, see types.Merge.
+func WithDefaultOption(option types.Option) ClientOption {
+	return func(c *httpClient) {
+		c.Default = types.Merge(c.Default, option)
+	}
+}
+
+// WithMiddleware wraps every request, watches included, with middlewares.
+// The first middleware is the outermost one, it sees a request before any retry.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *httpClient) {
+		c.Middlewares = append(c.Middlewares, middlewares...)
+	}
+}
+
+// WithRetryPolicy retries failed idempotent requests according to policy, instead of
+// client-go which retries responses with a Retry-After header on its own otherwise.
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *httpClient) {
+		c.Retry = policy
+	}
 }
 
 // NewForConfig returns http client interface
-func NewForConfig(cfg *rest.Config) (Interface, error) {
+func NewForConfig(cfg *rest.Config, opts ...ClientOption) (Interface, error) {
 	if nil == cfg {
 		return nil, errors.New("nil rest config")
 	}
+	c := &httpClient{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	cfg = rest.CopyConfig(cfg)
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &responseRecorder{rt: rt, noRetry: nil != c.Retry}
+	})
 	restCli, err := rest.RESTClientFor(cfg)
 	if nil != err {
 		return nil, err
 	}
-	return &httpClient{Client: restCli}, nil
+	c.Client = restCli
+	return c, nil
 }
 
-func (c *httpClient) Get(ctx context.Context, absPath string) ([]byte, error) {
-	req := c.Client.Get().AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
+// NewForClientConfig returns http client interface with the settings of cfg, opts are
+// applied after them.
+func NewForClientConfig(cfg *config.ClientConfig, opts ...ClientOption) (Interface, error) {
+	if nil == cfg {
+		return nil, errors.New("nil client config")
+	}
+	if nil != cfg.RetryPolicy {
+		opts = append([]ClientOption{WithRetryPolicy(cfg.RetryPolicy)}, opts...)
 	}
-	return req.DoRaw()
+	return NewForConfig(cfg.Config, opts...)
 }
 
-func (c *httpClient) List(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
-	req := c.Client.Get().AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
-	}
-	if nil != option {
-		req = option.ApplyToRequest(req)
+// Do makes the request through the middlewares, it's retried according to the retry policy
+// if it's idempotent. The response is returned as long as the server responded, non-2xx
+// responses come with a HTTPError.
+func (c *httpClient) Do(ctx context.Context, request *Request) (*Response, error) {
+	if nil == ctx {
+		ctx = context.Background()
 	}
-	return req.DoRaw()
+	return c.handler(c.do)(ctx, request)
 }
 
-func (c *httpClient) Create(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
-	req := c.Client.Post().AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
+// handler wraps h with the middlewares.
+func (c *httpClient) handler(h Handler) Handler {
+	for i := len(c.Middlewares) - 1; i >= 0; i-- {
+		h = c.Middlewares[i](h)
 	}
-	if nil != option {
-		req = option.ApplyToRequest(req)
+	return h
+}
+
+// option returns the option of request on top of the default one.
+func (c *httpClient) option(request *Request) types.Option {
+	if nil == c.Default {
+		return request.Option
 	}
-	req.Body(outBytes)
-	return req.DoRaw()
+	return types.Merge(c.Default, request.Option)
 }
 
-func (c *httpClient) Update(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
-	req := c.Client.Put().AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
+func (c *httpClient) do(ctx context.Context, request *Request) (*Response, error) {
+	option := c.option(request)
+	idempotent := types.IsIdempotent(option)
+	switch request.Verb {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		idempotent = true
 	}
-	if nil != option {
-		req = option.ApplyToRequest(req)
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp := &Response{}
+		req := c.newRequest(request).Context(WithResponse(ctx, resp))
+		if nil != option {
+			req = option.ApplyToRequest(req)
+		}
+		bt, err := req.DoRaw()
+		resp.Body, resp.Duration = bt, time.Since(start)
+		if nil != err && 0 != resp.StatusCode && isErrorStatus(resp.StatusCode) {
+			resp.Body = resp.errorBody
+			err = newHTTPError(request.Verb, resp, resp.errorBody)
+		}
+		resp.errorBody = nil
+		if 0 == resp.StatusCode {
+			resp = nil
+		}
+		if nil == err || !idempotent || nil != ctx.Err() {
+			return resp, err
+		}
+		statusCode, header := 0, http.Header(nil)
+		if nil != resp {
+			statusCode, header = resp.StatusCode, resp.Header
+		}
+		wait, retry := c.Retry.Backoff(attempt, statusCode, header)
+		if !retry {
+			return resp, err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
 	}
-	req.Body(outBytes)
-	return req.DoRaw()
 }
 
-func (c *httpClient) Patch(ctx context.Context, absPath string, pt types2.PatchType, outBytes []byte) ([]byte, error) {
-	req := c.Client.Patch(pt).AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
+func (c *httpClient) newRequest(request *Request) *rest.Request {
+	var req *rest.Request
+	if request.Verb == http.MethodPatch {
+		req = c.Client.Patch(request.PatchType)
+	} else {
+		req = c.Client.Verb(request.Verb)
+	}
+	req = req.AbsPath(request.AbsPath)
+	for k, v := range request.Header {
+		req = req.SetHeader(k, v...)
 	}
-	req.Body(outBytes)
-	return req.DoRaw()
+	if nil != request.Body {
+		req = req.Body(request.Body)
+	}
+	return req
+}
+
+func (c *httpClient) Get(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodGet, AbsPath: absPath, Option: option}))
+}
+
+func (c *httpClient) List(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodGet, AbsPath: absPath, Option: option, List: true}))
+}
+
+func (c *httpClient) Create(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPost, AbsPath: absPath, Body: outBytes, Option: option}))
+}
+
+func (c *httpClient) Update(ctx context.Context, absPath string, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPut, AbsPath: absPath, Body: outBytes, Option: option}))
+}
+
+func (c *httpClient) Patch(ctx context.Context, absPath string, pt types2.PatchType, outBytes []byte, option types.Option) ([]byte, error) {
+	return Body(c.Do(ctx, &Request{Verb: http.MethodPatch, AbsPath: absPath, PatchType: pt, Body: outBytes, Option: option}))
 }
 
 func (c *httpClient) Delete(ctx context.Context, absPath string, option types.Option) ([]byte, error) {
-	req := c.Client.Delete().AbsPath(absPath)
-	if nil != ctx {
-		req = req.Context(ctx)
-	}
-	if nil != option {
-		req = option.ApplyToRequest(req)
-	}
-	return req.DoRaw()
+	return Body(c.Do(ctx, &Request{Verb: http.MethodDelete, AbsPath: absPath, Option: option}))
 }
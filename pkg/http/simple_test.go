@@ -10,15 +10,26 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/alauda/kube-rest/pkg/config"
 	"github.com/alauda/kube-rest/pkg/types"
 
 	types2 "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
 )
 
 var defaultOptions = &types.Options{Header: url.Values{"Content-Type": []string{"application/json"}}}
 
+// paramOption is an Option which isn't a types.QueryOption.
+type paramOption struct {
+	key, value string
+}
+
+func (o paramOption) ApplyToRequest(req *rest.Request) *rest.Request {
+	return req.Param(o.key, o.value)
+}
+
 func getJSON(key, val string) []byte {
 	return []byte(fmt.Sprintf(`{%q: %q}`, key, val))
 }
@@ -249,7 +260,7 @@ func TestPatch(t *testing.T) {
 			params[k] = values[0]
 		}
 
-		got, err := cli.Patch(context.TODO(), path.Path, types2.StrategicMergePatchType, c.patch)
+		got, err := cli.Patch(context.TODO(), path.Path, types2.StrategicMergePatchType, c.patch, &types.Options{Params: params})
 
 		if nil != err {
 			t.Errorf("unexpected error when patching %q: %v", c.name, err)
@@ -447,7 +458,7 @@ func TestGet(t *testing.T) {
 			params[k] = values[0]
 		}
 
-		got, err := cli.Get(context.TODO(), path.Path)
+		got, err := cli.Get(context.TODO(), path.Path, &types.Options{Params: params})
 
 		if nil != err {
 			t.Errorf("unexpected error when getting %q: %v", c.name, err)
@@ -458,3 +469,375 @@ func TestGet(t *testing.T) {
 		}
 	}
 }
+
+func TestWatch(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		resp []string
+		want []Event
+	}{
+		{
+			name: "ndjson_watch",
+			path: "/test/",
+			resp: []string{
+				`{"type":"ADDED","object":{"a":"b"}}` + "\n",
+				`{"type":"MODIFIED","object":{"a":"c"}}` + "\n",
+				`{"type":"DELETED","object":{"a":"c"}}` + "\n",
+			},
+			want: []Event{
+				{Type: Added, Object: []byte(`{"a":"b"}`)},
+				{Type: Modified, Object: []byte(`{"a":"c"}`)},
+				{Type: Deleted, Object: []byte(`{"a":"c"}`)},
+			},
+		},
+		{
+			name: "chunked_watch",
+			path: "/test/",
+			resp: []string{
+				`{"type":"ADDED",`,
+				`"object":{"a":"b"}}{"type":"BOOKMARK","object":{}}`,
+			},
+			want: []Event{
+				{Type: Added, Object: []byte(`{"a":"b"}`)},
+				{Type: Bookmark, Object: []byte(`{}`)},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if "GET" != r.Method {
+				t.Errorf("Watch(%q) got HTTP method %s. wanted GET", c.name, r.Method)
+			}
+			if r.URL.Path != c.path {
+				t.Errorf("Watch(%q) got path %s. wanted %s", c.name, r.URL.Path, c.path)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			for _, chunk := range c.resp {
+				w.Write([]byte(chunk))
+				w.(http.Flusher).Flush()
+			}
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		events, err := cli.Watch(context.TODO(), c.path, nil)
+
+		if nil != err {
+			t.Errorf("unexpected error when watching %q: %v", c.name, err)
+			continue
+		}
+
+		got := []Event{}
+		for e := range events {
+			got = append(got, e)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Watch(%q) want: %s\ngot: %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestWatchCancel(t *testing.T) {
+	stop := make(chan struct{})
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"ADDED","object":{}}`))
+		w.(http.Flusher).Flush()
+		<-stop
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+	defer close(stop)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	events, err := cli.Watch(ctx, "/test", nil)
+	if nil != err {
+		t.Fatalf("unexpected error when watching: %v", err)
+	}
+	if e := <-events; e.Type != Added {
+		t.Errorf("Watch got event %s. wanted %s", e.Type, Added)
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestDo(t *testing.T) {
+	cases := []struct {
+		name     string
+		request  *Request
+		status   int
+		header   http.Header
+		resp     []byte
+		want     *Response
+		location string
+		wantErr  bool
+	}{
+		{
+			name:     "created",
+			request:  &Request{Verb: "POST", AbsPath: "/test/", Body: getJSON("a", "b"), Option: defaultOptions},
+			status:   http.StatusCreated,
+			header:   http.Header{"Location": []string{"a"}, "Etag": []string{`"1"`}},
+			resp:     getJSON("a", "b"),
+			want:     &Response{StatusCode: http.StatusCreated, Body: getJSON("a", "b")},
+			location: "/test/a",
+		},
+		{
+			name:    "patched",
+			request: &Request{Verb: "PATCH", AbsPath: "/test/a", PatchType: types2.MergePatchType, Body: getJSON("a", "c")},
+			status:  http.StatusOK,
+			resp:    getJSON("a", "c"),
+			want:    &Response{StatusCode: http.StatusOK, Body: getJSON("a", "c")},
+		},
+		{
+			name:    "not_found",
+			request: &Request{Verb: "GET", AbsPath: "/test/b"},
+			status:  http.StatusNotFound,
+			resp:    []byte("not found"),
+			want:    &Response{StatusCode: http.StatusNotFound, Body: []byte("not found")},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != c.request.Verb {
+				t.Errorf("Do(%q) got HTTP method %s. wanted %s", c.name, r.Method, c.request.Verb)
+			}
+			if r.URL.Path != c.request.AbsPath {
+				t.Errorf("Do(%q) got path %s. wanted %s", c.name, r.URL.Path, c.request.AbsPath)
+			}
+			if content := r.Header.Get("Content-Type"); len(c.request.PatchType) > 0 && content != string(c.request.PatchType) {
+				t.Errorf("Do(%q) got Content-Type %s. wanted %s", c.name, content, c.request.PatchType)
+			}
+			data, _ := ioutil.ReadAll(r.Body)
+			if !reflect.DeepEqual(data, c.request.Body) && len(data)+len(c.request.Body) > 0 {
+				t.Errorf("Do(%q) got data %s. wanted %s", c.name, data, c.request.Body)
+			}
+			for k, v := range c.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(c.status)
+			w.Write(c.resp)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		got, err := cli.Do(context.TODO(), c.request)
+
+		if c.wantErr != (nil != err) {
+			t.Errorf("Do(%q) got error %v. wanted error: %v", c.name, err, c.wantErr)
+		}
+		if nil == got {
+			t.Errorf("Do(%q) got no response", c.name)
+			continue
+		}
+		for k := range c.header {
+			if got.Header.Get(k) != c.header.Get(k) {
+				t.Errorf("Do(%q) got header %s: %s. wanted %s", c.name, k, got.Header.Get(k), c.header.Get(k))
+			}
+		}
+		if got.Duration <= 0 {
+			t.Errorf("Do(%q) got duration %v. wanted a positive one", c.name, got.Duration)
+		}
+		if len(c.location) > 0 {
+			if location, err := got.Location(c.request.AbsPath); nil != err || location.String() != c.location {
+				t.Errorf("Do(%q) got location %v, %v. wanted %s", c.name, location, err, c.location)
+			}
+		}
+		got.Header, got.Duration = nil, 0
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Do(%q) want: %+v\ngot: %+v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	limit := &types.ListOptions{Limit: 10}
+	cases := []struct {
+		name       string
+		option     types.Option
+		query      url.Values
+		header     map[string]string
+		idempotent bool
+	}{
+		{
+			name:   "with",
+			option: types.Merge(types.WithHeader("X-Tenant", "a"), types.WithParam("page", "1"), types.WithDryRun()),
+			query:  url.Values{"page": []string{"1"}, "dryRun": []string{"All"}},
+			header: map[string]string{"X-Tenant": "a"},
+		},
+		{
+			name: "later_wins",
+			option: types.Merge(
+				types.WithHeader("X-Tenant", "a"),
+				nil,
+				types.Merge(types.WithParams(types.QueryParameters{"page": "1", "size": "5"}), limit),
+				&types.Options{Header: url.Values{"X-Tenant": []string{"b"}}, Params: types.QueryParameters{"page": "2"}, Idempotent: true},
+			),
+			query:      url.Values{"page": []string{"2"}, "size": []string{"5"}, "limit": []string{"10"}},
+			header:     map[string]string{"X-Tenant": "b"},
+			idempotent: true,
+		},
+		{
+			name:   "multi_valued",
+			option: &types.Options{Params: types.QueryParameters{"page": "1", "tag": "c"}, Query: url.Values{"tag": []string{"b", "a"}}},
+			query:  url.Values{"page": []string{"1"}, "tag": []string{"c", "b", "a"}},
+		},
+		{
+			name:   "multi_valued_later_wins",
+			option: types.Merge(types.WithQuery("tag", "b", "a"), types.WithParam("tag", "c"), types.WithQuery("id", "1", "2")),
+			query:  url.Values{"tag": []string{"c"}, "id": []string{"1", "2"}},
+		},
+		{
+			name:   "later_wins_over_list_options",
+			option: types.Merge(limit, types.WithParam("limit", "20"), types.WithQuery("tag", "a")),
+			query:  url.Values{"limit": []string{"20"}, "tag": []string{"a"}},
+		},
+		{
+			name:   "other_options_add",
+			option: types.Merge(paramOption{"tag", "a"}, types.WithParam("tag", "b"), types.Merge(limit, paramOption{"limit", "5"})),
+			query:  url.Values{"tag": []string{"a", "b"}, "limit": []string{"5", "10"}},
+		},
+		{
+			name:   "later_wins_over_delete_options",
+			option: types.Merge(&types.DeleteOptions{DryRun: []string{types.DryRunAll}}, types.WithQuery("dryRun", "x", "y")),
+			query:  url.Values{"dryRun": []string{"x", "y"}},
+		},
+		{
+			name:   "basic_auth_and_timeout",
+			option: types.Merge(types.WithBasicAuth("user", "pass"), types.WithTimeout(time.Minute)),
+			query:  url.Values{"timeout": []string{"1m0s"}},
+			header: map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if !reflect.DeepEqual(r.URL.Query(), c.query) {
+				t.Errorf("Options(%q) got query %v. wanted %v", c.name, r.URL.Query(), c.query)
+			}
+			for k, v := range c.header {
+				if got := r.Header.Get(k); got != v {
+					t.Errorf("Options(%q) got header %s %q. wanted %q", c.name, k, got, v)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(getJSON("a", "b"))
+		})
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+		defer srv.Close()
+
+		if _, err := cli.Get(context.TODO(), "/test", c.option); nil != err {
+			t.Errorf("unexpected error when getting %q: %v", c.name, err)
+		}
+		if types.IsIdempotent(c.option) != c.idempotent {
+			t.Errorf("Options(%q) got idempotent %v. wanted %v", c.name, !c.idempotent, c.idempotent)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		bt, _ := json.Marshal(map[string]string{
+			"tenant": r.Header.Get("X-Tenant"),
+			"trace":  r.Header.Get("X-Trace"),
+			"query":  r.URL.RawQuery,
+		})
+		w.Write(bt)
+	}))
+	defer svr.Close()
+	cfg, err := config.GetDefaultConfig(svr.URL)
+	if nil != err {
+		t.Fatalf("unexpected error when creating config: %v", err)
+	}
+
+	seen := []string{}
+	record := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, request *Request) (*Response, error) {
+				seen = append(seen, name+" "+request.Verb+" "+request.AbsPath)
+				return next(ctx, request)
+			}
+		}
+	}
+	tenant := func(next Handler) Handler {
+		return func(ctx context.Context, request *Request) (*Response, error) {
+			r := *request
+			r.Option = types.Merge(types.WithHeader("X-Tenant", "t1"), r.Option)
+			return next(ctx, &r)
+		}
+	}
+	cli, err := NewForConfig(cfg,
+		WithDefaultOption(types.Merge(defaultOptions, types.WithHeader("X-Trace", "default"), types.WithParam("page", "1"))),
+		WithMiddleware(record("outer"), tenant, record("inner")),
+	)
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		option types.Option
+		want   map[string]string
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"tenant": "t1", "trace": "default", "query": "page=1"},
+		},
+		{
+			name:   "overridden",
+			option: types.Merge(types.WithHeader("X-Tenant", "t2"), types.WithHeader("X-Trace", "call"), types.WithParam("page", "2")),
+			want:   map[string]string{"tenant": "t2", "trace": "call", "query": "page=2"},
+		},
+		{
+			name:   "overridden_by_list_options",
+			option: &types.ListOptions{Limit: 10, Params: url.Values{"page": []string{"3"}}},
+			want:   map[string]string{"tenant": "t1", "trace": "default", "query": "limit=10&page=3"},
+		},
+	}
+	for _, c := range cases {
+		bt, err := cli.Get(context.TODO(), "/test", c.option)
+		if nil != err {
+			t.Errorf("unexpected error when getting %q: %v", c.name, err)
+			continue
+		}
+		got := map[string]string{}
+		json.Unmarshal(bt, &got)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Middleware(%q) want: %v\ngot: %v", c.name, c.want, got)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	if _, err := cli.Watch(ctx, "/test", nil); nil != err {
+		t.Errorf("unexpected error when watching: %v", err)
+	}
+	want := []string{}
+	for i := 0; i <= len(cases); i++ {
+		want = append(want, "outer GET /test", "inner GET /test")
+	}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("Middleware want: %v\ngot: %v", want, seen)
+	}
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// EventType defines the possible types of watch events.
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+	Bookmark EventType = "BOOKMARK"
+	Error    EventType = "ERROR"
+)
+
+// Event represents a single event to a watched resource.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Object is the raw data of the changed object.
+	// If Type is Error, Object is the raw data of the status describing the error.
+	Object json.RawMessage `json:"object"`
+}
+
+// Watch streams the events of absPath, the watch request goes through the middlewares
+// as a GET request whose response has no body.
+func (c *httpClient) Watch(ctx context.Context, absPath string, option types.Option) (<-chan Event, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	var events chan Event
+	_, err := c.handler(func(ctx context.Context, request *Request) (*Response, error) {
+		resp := &Response{}
+		req := c.newRequest(request).Context(WithResponse(ctx, resp))
+		if option := c.option(request); nil != option {
+			req = option.ApplyToRequest(req)
+		}
+		stream, err := req.Stream()
+		if nil != err {
+			if 0 != resp.StatusCode && isErrorStatus(resp.StatusCode) {
+				return resp, newHTTPError(http.MethodGet, resp, resp.errorBody)
+			}
+			return nil, err
+		}
+		events = make(chan Event)
+		go decodeEvents(ctx, stream, events)
+		return resp, nil
+	})(ctx, &Request{Verb: http.MethodGet, AbsPath: absPath, Option: option})
+	if nil != err {
+		return nil, err
+	}
+	if nil == events {
+		return nil, errors.New("watch request not made by the middlewares")
+	}
+	return events, nil
+}
+
+// decodeEvents decodes newline-delimited or concatenated json events from stream
+// until the stream ends or ctx is done, events is closed on return.
+func decodeEvents(ctx context.Context, stream io.ReadCloser, events chan<- Event) {
+	defer close(events)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// unblock the decoder as soon as the watch is cancelled
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		stream.Close()
+	}()
+
+	decoder := json.NewDecoder(stream)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); nil != err {
+			if err == io.EOF || nil != ctx.Err() {
+				return
+			}
+			status := apiError.NewInternalError(err).Status()
+			event = Event{Type: Error}
+			event.Object, _ = json.Marshal(&status)
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+			return
+		}
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/alauda/kube-rest/pkg/types"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// DefaultBulkWorkers is the number of concurrent requests of bulk operations without Workers.
+const DefaultBulkWorkers = 8
+
+// ErrSkipped is the error of the objects not processed after a failure of a bulk operation.
+var ErrSkipped = errors.New("skipped after a previous failure")
+
+// BulkOptions configures bulk operations.
+//
+// The requests are also throttled by the QPS and Burst of the rest.Config of the client.
+type BulkOptions struct {
+	// Workers bounds the number of concurrent requests, DefaultBulkWorkers if zero.
+	Workers int
+	// ContinueOnError processes all objects regardless of failures, otherwise
+	// the objects not processed yet are skipped after the first failure.
+	ContinueOnError bool
+	// Option is applied to every request.
+	Option types.Option
+}
+
+// BulkResult is the result of an object of a bulk operation.
+type BulkResult struct {
+	Object Object
+	Err    error
+}
+
+// BulkReport holds the results of a bulk operation, in the order of its objects.
+type BulkReport struct {
+	Results []BulkResult
+}
+
+// Succeeded returns the objects processed successfully.
+func (r *BulkReport) Succeeded() []Object {
+	objects := []Object{}
+	for _, result := range r.Results {
+		if nil == result.Err {
+			objects = append(objects, result.Object)
+		}
+	}
+	return objects
+}
+
+// Failed returns the results of the objects failed or skipped.
+func (r *BulkReport) Failed() []BulkResult {
+	results := []BulkResult{}
+	for _, result := range r.Results {
+		if nil != result.Err {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// Errors returns the aggregated errors of the failed objects, skipped ones excluded,
+// or nil if none failed.
+func (r *BulkReport) Errors() error {
+	errs := []error{}
+	for _, result := range r.Results {
+		if nil != result.Err && result.Err != ErrSkipped {
+			errs = append(errs, result.Err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// CreateAll creates objs concurrently, the returned error aggregates the failures of the report.
+func CreateAll(ctx context.Context, c Client, objs []Object, opts BulkOptions) (*BulkReport, error) {
+	return bulk(ctx, objs, opts, func(ctx context.Context, obj Object) error {
+		return c.Create(ctx, obj, opts.Option)
+	})
+}
+
+// UpdateAll updates objs concurrently, the returned error aggregates the failures of the report.
+func UpdateAll(ctx context.Context, c Client, objs []Object, opts BulkOptions) (*BulkReport, error) {
+	return bulk(ctx, objs, opts, func(ctx context.Context, obj Object) error {
+		return c.Update(ctx, obj, opts.Option)
+	})
+}
+
+// DeleteAll deletes objs concurrently, the returned error aggregates the failures of the report.
+func DeleteAll(ctx context.Context, c Client, objs []Object, opts BulkOptions) (*BulkReport, error) {
+	return bulk(ctx, objs, opts, func(ctx context.Context, obj Object) error {
+		_, err := c.Delete(ctx, obj, opts.Option)
+		return err
+	})
+}
+
+// GetAll gets objs concurrently, the returned error aggregates the failures of the report.
+func GetAll(ctx context.Context, c Client, objs []Object, opts BulkOptions) (*BulkReport, error) {
+	return bulk(ctx, objs, opts, func(ctx context.Context, obj Object) error {
+		return c.Get(ctx, obj, opts.Option)
+	})
+}
+
+// bulk calls do for every object of objs from a pool of workers.
+func bulk(ctx context.Context, objs []Object, opts BulkOptions, do func(context.Context, Object) error) (*BulkReport, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultBulkWorkers
+	}
+	report := &BulkReport{Results: make([]BulkResult, len(objs))}
+	for i, obj := range objs {
+		report.Results[i] = BulkResult{Object: obj, Err: ErrSkipped}
+	}
+
+	var lock sync.Mutex
+	failed := false
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(objs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				lock.Lock()
+				stop := failed && !opts.ContinueOnError
+				lock.Unlock()
+				if stop {
+					continue
+				}
+				err := do(ctx, objs[i])
+				lock.Lock()
+				report.Results[i].Err = err
+				failed = failed || nil != err
+				lock.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for i := range objs {
+		lock.Lock()
+		stop := failed && !opts.ContinueOnError
+		lock.Unlock()
+		if stop {
+			break
+		}
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			for j := i; j < len(objs); j++ {
+				report.Results[j].Err = ctx.Err()
+			}
+			break dispatch
+		}
+	}
+	close(indices)
+	wg.Wait()
+	return report, report.Errors()
+}
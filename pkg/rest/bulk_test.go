@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBulk(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    BulkOptions
+		do      func(context.Context, Client, []Object, BulkOptions) (*BulkReport, error)
+		method  string
+		names   []string
+		results []string
+		errs    int
+	}{
+		{
+			name:    "create_all",
+			opts:    BulkOptions{Workers: 2},
+			do:      CreateAll,
+			method:  "POST",
+			names:   []string{"a", "b", "c", "d"},
+			results: []string{"", "", "", ""},
+		},
+		{
+			name:    "update_continue_on_error",
+			opts:    BulkOptions{Workers: 2, ContinueOnError: true},
+			do:      UpdateAll,
+			method:  "PUT",
+			names:   []string{"a", "bad", "c", "d"},
+			results: []string{"", "failed", "", ""},
+			errs:    1,
+		},
+		{
+			name:    "delete_stop_on_error",
+			opts:    BulkOptions{Workers: 1},
+			do:      DeleteAll,
+			method:  "DELETE",
+			names:   []string{"a", "bad", "c", "d"},
+			results: []string{"", "failed", "skipped", "skipped"},
+			errs:    1,
+		},
+		{
+			name:    "get_all",
+			do:      GetAll,
+			method:  "GET",
+			names:   []string{"a", "b"},
+			results: []string{"", ""},
+		},
+	}
+
+	for _, c := range cases {
+		var lock sync.Mutex
+		running, maxRunning := 0, 0
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			lock.Lock()
+			running--
+			lock.Unlock()
+
+			obj := &testObj{}
+			json.NewDecoder(r.Body).Decode(obj)
+			if r.Method != c.method || obj.Name == "bad" || r.URL.Path == "/test/bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(getJSON("a", "1"))
+		})
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+		defer srv.Close()
+
+		objs := []Object{}
+		for _, name := range c.names {
+			objs = append(objs, &testObj{Name: name})
+		}
+		report, err := c.do(context.TODO(), cli, objs, c.opts)
+		if len(report.Results) != len(c.results) {
+			t.Errorf("Bulk(%q) got %d results. wanted %d", c.name, len(report.Results), len(c.results))
+			continue
+		}
+		for i, result := range report.Results {
+			got := ""
+			switch {
+			case result.Err == ErrSkipped:
+				got = "skipped"
+			case nil != result.Err:
+				got = "failed"
+			}
+			if got != c.results[i] || result.Object != objs[i] {
+				t.Errorf("Bulk(%q) got result %d %s. wanted %s", c.name, i, got, c.results[i])
+			}
+		}
+		if len(report.Failed()) != len(c.results)-len(report.Succeeded()) {
+			t.Errorf("Bulk(%q) got %d failed and %d succeeded", c.name, len(report.Failed()), len(report.Succeeded()))
+		}
+		if (c.errs == 0) != (nil == err) {
+			t.Errorf("Bulk(%q) got error %v", c.name, err)
+		}
+		workers := c.opts.Workers
+		if 0 == workers {
+			workers = DefaultBulkWorkers
+		}
+		if maxRunning > workers {
+			t.Errorf("Bulk(%q) got %d concurrent requests. wanted at most %d", c.name, maxRunning, workers)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"reflect"
 
+	"github.com/alauda/kube-rest/pkg/config"
 	"github.com/alauda/kube-rest/pkg/http"
 	"github.com/alauda/kube-rest/pkg/types"
 
 	apiError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types2 "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/rest"
 )
 
@@ -15,93 +22,299 @@ var _ Client = &client{}
 
 type client struct {
 	Client http.Interface
+	// Codecs are the codecs negotiated with the server, the first one encodes the requests.
+	// Without codecs, the data of objects is sent and parsed as is.
+	Codecs []Codec
 }
 
-func handleError(bt []byte, err error) error {
-	switch t := err.(type) {
-	case apiError.APIStatus:
-		reason := t.Status().Reason
-		// handle k8s ignored message in error
-		if reason == metav1.StatusReasonBadRequest ||
-			reason == metav1.StatusReasonConflict ||
-			reason == metav1.StatusReasonAlreadyExists {
-			ret := &apiError.StatusError{
-				ErrStatus: t.Status(),
-			}
-			ret.ErrStatus.Message = string(bt)
-			return ret
+// negotiate adds the Content-Type and Accept headers of the codecs to request, the default
+// option of the http client and the option of the request could still override them.
+func (c *client) negotiate(request *http.Request) {
+	if len(c.Codecs) == 0 {
+		return
+	}
+	request.Header = nethttp.Header{"Accept": []string{accept(c.Codecs)}}
+	if nil != request.Body && request.Verb != nethttp.MethodPatch {
+		request.Header.Set("Content-Type", c.Codecs[0].ContentType())
+	}
+}
+
+// encode returns the data of obj to send.
+func (c *client) encode(obj Object) ([]byte, error) {
+	if len(c.Codecs) == 0 {
+		return obj.Data()
+	}
+	return encode(c.Codecs[0], obj)
+}
+
+// parse parses bt into target with the codec of contentType, as is if there is none.
+func (c *client) parse(contentType string, bt []byte, target interface{ Parse([]byte) error }) error {
+	codec := codecFor(c.Codecs, contentType)
+	if nil == codec {
+		return target.Parse(bt)
+	}
+	return decode(codec, bt, target)
+}
+
+// do makes request for obj, the request is conditional on the ETag of obj if it's Versioned.
+func (c *client) do(ctx context.Context, obj Object, request *http.Request) (*http.Response, error) {
+	versioned, _ := obj.(Versioned)
+	etag := ""
+	if nil != versioned {
+		etag = versioned.GetETag()
+	}
+	if len(etag) > 0 && request.Verb != nethttp.MethodGet && request.Verb != nethttp.MethodPost {
+		request.Option = &chainOption{request.Option, ifMatch(etag)}
+	}
+	c.negotiate(request)
+	resp, err := c.Client.Do(ctx, request)
+	if nil != err {
+		if len(etag) > 0 && http.IsPreconditionFailed(err) {
+			err = &VersionConflictError{ETag: etag, Err: err}
 		}
+		return nil, err
 	}
-	return err
+	return resp, nil
+}
+
+// parseObject parses the response of a request for obj into obj, then records the ETag of
+// the response into obj if it's Versioned, parsing could have reset its previous one.
+func (c *client) parseObject(resp *http.Response, obj Object) error {
+	if err := c.parse(resp.Header.Get("Content-Type"), resp.Body, obj); nil != err {
+		return err
+	}
+	if versioned, ok := obj.(Versioned); ok {
+		if etag := resp.Header.Get("ETag"); len(etag) > 0 {
+			versioned.SetETag(etag)
+		}
+	}
+	return nil
 }
 
 // Create implements client.Client
 func (c *client) Create(ctx context.Context, obj Object, option types.Option) error {
-	data, err := obj.Data()
+	data, err := c.encode(obj)
 	if nil != err {
 		return err
 	}
-	data, err = c.Client.Create(ctx, obj.TypeLink(), data, option)
+	resp, err := c.do(ctx, obj, &http.Request{Verb: nethttp.MethodPost, AbsPath: obj.TypeLink(), Body: data, Option: option})
 	if nil != err {
-		return handleError(data, err)
+		return err
 	}
-	return obj.Parse(data)
+	return c.parseObject(resp, obj)
 }
 
 // Update implements client.Client
 func (c *client) Update(ctx context.Context, obj Object, option types.Option) error {
-	data, err := obj.Data()
+	data, err := c.encode(obj)
 	if nil != err {
 		return err
 	}
-	data, err = c.Client.Update(ctx, obj.SelfLink(), data, option)
+	resp, err := c.do(ctx, obj, &http.Request{Verb: nethttp.MethodPut, AbsPath: obj.SelfLink(), Body: data, Option: option})
 	if nil != err {
-		return handleError(data, err)
+		return err
 	}
-	return obj.Parse(data)
+	return c.parseObject(resp, obj)
 }
 
-func (c *client) Get(ctx context.Context, obj Object) error {
-	var bt []byte
-	var err error
-	if bt, err = c.Client.Get(ctx, obj.SelfLink()); nil != err {
-		return handleError(bt, err)
+func (c *client) Get(ctx context.Context, obj Object, option types.Option) error {
+	resp, err := c.do(ctx, obj, &http.Request{Verb: nethttp.MethodGet, AbsPath: obj.SelfLink(), Option: option})
+	if nil != err {
+		return err
 	}
-	return obj.Parse(bt)
+	return c.parseObject(resp, obj)
 }
 
 func (c *client) List(ctx context.Context, obj ObjectList, option types.Option) error {
-	var bt []byte
-	var err error
-	if bt, err = c.Client.List(ctx, obj.TypeLink(), option); nil != err {
-		return handleError(bt, err)
+	_, err := c.list(ctx, obj, option)
+	return err
+}
+
+// list lists obj and returns the response, whose header the pager follows.
+func (c *client) list(ctx context.Context, obj ObjectList, option types.Option) (*http.Response, error) {
+	request := &http.Request{Verb: nethttp.MethodGet, AbsPath: obj.TypeLink(), Option: option, List: true}
+	c.negotiate(request)
+	resp, err := c.Client.Do(ctx, request)
+	if nil != err {
+		return nil, err
 	}
-	return obj.Parse(bt)
+	return resp, c.parse(resp.Header.Get("Content-Type"), resp.Body, obj)
 }
 
-func (c *client) Delete(ctx context.Context, obj Object, option types.Option) error {
-	_, err := c.Client.Delete(ctx, obj.SelfLink(), option)
-	return err
+func (c *client) Delete(ctx context.Context, obj Object, option types.Option) (*metav1.Status, error) {
+	resp, err := c.do(ctx, obj, &http.Request{Verb: nethttp.MethodDelete, AbsPath: obj.SelfLink(), Option: option})
+	if nil != err {
+		return nil, err
+	}
+	return c.parseDeleted(resp.Header.Get("Content-Type"), resp.Body, obj)
 }
 
-func (c *client) Patch(ctx context.Context, obj Object, patch Patch) error {
+func (c *client) DeleteCollection(ctx context.Context, list ObjectList, option types.Option) (*metav1.Status, error) {
+	request := &http.Request{Verb: nethttp.MethodDelete, AbsPath: list.TypeLink(), Option: option}
+	c.negotiate(request)
+	resp, err := c.Client.Do(ctx, request)
+	if nil != err {
+		return nil, err
+	}
+	return c.parseDeleted(resp.Header.Get("Content-Type"), resp.Body, list)
+}
+
+func (c *client) Patch(ctx context.Context, obj Object, patch Patch, option types.Option) error {
 	var bt []byte
 	var err error
 	bt, err = patch.Data(obj)
 	if nil != err {
 		return err
 	}
-	if bt, err = c.Client.Patch(ctx, obj.TypeLink(), patch.Type(), bt); nil != err {
-		return handleError(bt, err)
+	// patches like server-side apply carry their own query parameters
+	if patchOption, ok := patch.(types.Option); ok {
+		option = &chainOption{patchOption, option}
+	}
+	request := &http.Request{Verb: nethttp.MethodPatch, AbsPath: patchLink(obj, patch), PatchType: patch.Type(), Body: bt, Option: option}
+	resp, err := c.do(ctx, obj, request)
+	if nil != err {
+		return err
+	}
+	return c.parseObject(resp, obj)
+}
+
+// patchLink returns the path patch is sent to: the SelfLink of obj for an apply patch, which
+// creates the object if it doesn't exist, its TypeLink for the others.
+func patchLink(obj Object, patch Patch) string {
+	if patch.Type() == types2.ApplyPatchType {
+		return obj.SelfLink()
 	}
-	return obj.Parse(bt)
+	return obj.TypeLink()
 }
 
-// NewForConfig creates a new rest client
-func NewForConfig(cfg *rest.Config) (Client, error) {
-	restClient, err := http.NewForConfig(cfg)
+func (c *client) Watch(ctx context.Context, obj Object, option types.Option) (<-chan Event, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	raw, err := c.Client.Watch(ctx, obj.TypeLink(), option)
 	if nil != err {
 		return nil, err
 	}
-	return &client{Client: restClient}, nil
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for e := range raw {
+			event := Event{Type: e.Type}
+			if e.Type == http.Error {
+				event.Err = watchError(e.Object)
+			} else {
+				event.Object = NewObject(obj)
+				if err := event.Object.Parse(e.Object); nil != err {
+					event = Event{Type: http.Error, Err: err}
+				}
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// watchError converts the object of an error event to error.
+func watchError(bt []byte) error {
+	status := metav1.Status{}
+	if err := json.Unmarshal(bt, &status); nil != err || len(status.Message) == 0 {
+		status = apiError.NewInternalError(errors.New(string(bt))).Status()
+	}
+	return &apiError.StatusError{ErrStatus: status}
+}
+
+// parseDeleted parses the response of a delete request of target, which is either the
+// deleted object, a Status, or anything else, e.g. empty or a text message, which is
+// ignored. The response is only parsed if its Content-Type has a codec, or is json.
+func (c *client) parseDeleted(contentType string, bt []byte, target interface{ Parse([]byte) error }) (*metav1.Status, error) {
+	if len(bytes.TrimSpace(bt)) == 0 {
+		return nil, nil
+	}
+	codec := codecFor(c.Codecs, contentType)
+	if nil == codec {
+		codec = codecFor([]Codec{JSONCodec}, contentType)
+	}
+	if nil == codec || (isJSON(codec) && !json.Valid(bt)) {
+		return nil, nil
+	}
+	meta := metav1.TypeMeta{}
+	if err := codec.Decode(bt, &meta); nil == err && meta.Kind == "Status" {
+		status := &metav1.Status{}
+		if err := codec.Decode(bt, status); nil != err {
+			return nil, err
+		}
+		return status, nil
+	}
+	return nil, decode(codec, bt, target)
+}
+
+// NewObject creates a new, empty instance of obj, with its New method if it's a Prototype.
+func NewObject(obj Object) Object {
+	if p, ok := obj.(Prototype); ok {
+		return p.New()
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface().(Object)
+	}
+	return reflect.New(t).Elem().Interface().(Object)
+}
+
+// chainOption applies first and then second.
+type chainOption struct {
+	first  types.Option
+	second types.Option
+}
+
+func (o *chainOption) ApplyToRequest(req *rest.Request) *rest.Request {
+	for _, option := range []types.Option{o.first, o.second} {
+		if nil != option {
+			req = option.ApplyToRequest(req)
+		}
+	}
+	return req
+}
+
+// IsIdempotent implements types.Idempotent
+func (o *chainOption) IsIdempotent() bool {
+	return types.IsIdempotent(o.first) || types.IsIdempotent(o.second)
+}
+
+// NewForConfig creates a new rest client speaking json, opts configure its underlying
+// http client.
+func NewForConfig(cfg *rest.Config, opts ...http.ClientOption) (Client, error) {
+	return NewForConfigWithCodecs(cfg, []Codec{JSONCodec}, opts...)
+}
+
+// NewForClientConfig creates a new rest client speaking json with the settings of cfg,
+// e.g. its retry policy, opts configure its underlying http client after them.
+func NewForClientConfig(cfg *config.ClientConfig, opts ...http.ClientOption) (Client, error) {
+	restClient, err := http.NewForClientConfig(cfg, opts...)
+	if nil != err {
+		return nil, err
+	}
+	return NewForInterface(restClient, JSONCodec), nil
+}
+
+// NewForConfigWithCodecs creates a new rest client speaking codecs, see NewForInterface.
+func NewForConfigWithCodecs(cfg *rest.Config, codecs []Codec, opts ...http.ClientOption) (Client, error) {
+	restClient, err := http.NewForConfig(cfg, opts...)
+	if nil != err {
+		return nil, err
+	}
+	return NewForInterface(restClient, codecs...), nil
+}
+
+// NewForInterface creates a new rest client making its requests with c,
+// e.g. a http client wrapped with http.NewCachingClient.
+//
+// With codecs, requests are encoded with the first codec and accept all of them, and
+// responses are decoded with the codec of their Content-Type. Without codecs, the data of
+// objects is sent and parsed as is.
+func NewForInterface(c http.Interface, codecs ...Codec) Client {
+	return &client{Client: c, Codecs: codecs}
 }
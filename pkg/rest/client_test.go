@@ -14,8 +14,14 @@ import (
 	"testing"
 
 	"github.com/alauda/kube-rest/pkg/config"
+	http2 "github.com/alauda/kube-rest/pkg/http"
 	"github.com/alauda/kube-rest/pkg/types"
 
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 	types2 "k8s.io/apimachinery/pkg/types"
 )
 
@@ -98,7 +104,7 @@ func TestGet(t *testing.T) {
 	}{
 		{
 			name: "normal_get",
-			path: "/test/a",
+			path: "/test/a?resourceVersion=1",
 			resp: getJSON("a", "b"),
 			want: &testObj{"a", "b"},
 		},
@@ -120,6 +126,10 @@ func TestGet(t *testing.T) {
 				t.Errorf("Get(%q) got path %s. wanted %s", c.name, r.URL.Path, path.Path)
 			}
 
+			if !reflect.DeepEqual(r.URL.Query(), path.Query()) {
+				t.Errorf("Get(%q) got query %v. wanted %v", c.name, r.URL.Query(), path.Query())
+			}
+
 			w.Header().Set("Content-Type", "application/json")
 			w.Write(c.resp)
 		})
@@ -139,7 +149,7 @@ func TestGet(t *testing.T) {
 
 		got := &testObj{Name: "a"}
 
-		err = cli.Get(context.TODO(), got)
+		err = cli.Get(context.TODO(), got, &types.Options{Params: params})
 
 		if nil != err {
 			t.Errorf("unexpected error when get %q: %v", c.name, err)
@@ -152,22 +162,40 @@ func TestGet(t *testing.T) {
 }
 
 func TestList(t *testing.T) {
+	timeout := int64(30)
 	cases := []struct {
-		name string
-		path string
-		resp []byte
-		want ObjectList
+		name   string
+		path   string
+		option types.Option
+		resp   []byte
+		want   ObjectList
 	}{
 		{
-			name: "normal_get",
-			path: "/test?filter=a",
-			resp: getJSONList(getJSON("a", "b")),
+			name:   "normal_get",
+			path:   "/test?filter=a",
+			option: &types.Options{Params: map[string]string{"filter": "a"}},
+			resp:   getJSONList(getJSON("a", "b")),
 			want: &testObjList{
 				Items: []testObj{
 					{Name: "a", ID: "b"},
 				},
 			},
 		},
+		{
+			name: "list_options",
+			path: "/test?labelSelector=app%3Da%2Ctier%21%3Ddb&fieldSelector=name%3Da&limit=10&continue=c1&resourceVersion=5&timeoutSeconds=30&tag=x&tag=y",
+			option: &types.ListOptions{
+				LabelSelector:   labels.SelectorFromSet(labels.Set{"app": "a"}).Add(mustRequirement("tier", selection.NotEquals, "db")),
+				FieldSelector:   fields.OneTermEqualSelector("name", "a"),
+				Limit:           10,
+				Continue:        "c1",
+				ResourceVersion: "5",
+				TimeoutSeconds:  &timeout,
+				Params:          url.Values{"tag": []string{"x", "y"}},
+			},
+			resp: getJSONList(),
+			want: &testObjList{Items: []testObj{}},
+		},
 	}
 
 	for _, c := range cases {
@@ -202,7 +230,7 @@ func TestList(t *testing.T) {
 		defer srv.Close()
 
 		got := &testObjList{}
-		err = cli.List(context.TODO(), got, &types.Options{Params: map[string]string{"filter": "a"}})
+		err = cli.List(context.TODO(), got, c.option)
 
 		if nil != err {
 			t.Errorf("unexpected error when listing %q: %v", c.name, err)
@@ -214,18 +242,73 @@ func TestList(t *testing.T) {
 	}
 }
 
+func mustRequirement(key string, op selection.Operator, values ...string) labels.Requirement {
+	r, err := labels.NewRequirement(key, op, values)
+	if nil != err {
+		panic(err)
+	}
+	return *r
+}
+
 func TestDelete(t *testing.T) {
+	grace := int64(0)
+	uid := "u1"
+	orphan := types.DeletePropagationOrphan
 	cases := []struct {
-		name string
-		path string
-		resp []byte
-		want Object
+		name       string
+		path       string
+		option     types.Option
+		body       string
+		respType   string
+		resp       []byte
+		want       Object
+		wantStatus string
 	}{
 		{
-			name: "normal_delete",
-			path: "/test/a?dryRun=false",
+			name:   "normal_delete",
+			path:   "/test/a?dryRun=false",
+			option: &types.Options{Params: map[string]string{"dryRun": "false"}},
+			want:   &testObj{Name: "a"},
+		},
+		{
+			name:   "query_options",
+			path:   "/test/a?gracePeriodSeconds=0&propagationPolicy=Orphan&dryRun=All",
+			option: &types.DeleteOptions{GracePeriodSeconds: &grace, PropagationPolicy: &orphan, DryRun: []string{types.DryRunAll}},
+			want:   &testObj{Name: "a"},
+		},
+		{
+			name:   "body_options",
+			path:   "/test/a",
+			option: &types.DeleteOptions{Preconditions: &types.Preconditions{UID: &uid}, PropagationPolicy: &orphan},
+			body:   `{"kind":"DeleteOptions","apiVersion":"v1","preconditions":{"uid":"u1"},"propagationPolicy":"Orphan"}`,
+			want:   &testObj{Name: "a"},
+		},
+		{
+			name: "final_object",
+			path: "/test/a",
+			resp: getJSON("a", "b"),
 			want: &testObj{Name: "a", ID: "b"},
 		},
+		{
+			name:       "status",
+			path:       "/test/a",
+			resp:       []byte(`{"kind":"Status","apiVersion":"v1","status":"Success"}`),
+			want:       &testObj{Name: "a"},
+			wantStatus: metav1.StatusSuccess,
+		},
+		{
+			name:     "text",
+			path:     "/test/a",
+			respType: "text/plain; charset=utf-8",
+			resp:     []byte("deleted"),
+			want:     &testObj{Name: "a"},
+		},
+		{
+			name: "invalid_json",
+			path: "/test/a",
+			resp: []byte("deleted"),
+			want: &testObj{Name: "a"},
+		},
 	}
 
 	for _, c := range cases {
@@ -248,7 +331,16 @@ func TestDelete(t *testing.T) {
 				t.Errorf("Delete(%q) got query %v. wanted %v", c.name, r.URL.Query(), path.Query())
 			}
 
-			w.Header().Set("Content-Type", "application/json")
+			if body, _ := ioutil.ReadAll(r.Body); string(body) != c.body {
+				t.Errorf("Delete(%q) got body %s. wanted %s", c.name, body, c.body)
+			}
+
+			respType := "application/json"
+			if len(c.respType) > 0 {
+				respType = c.respType
+			}
+			w.Header().Set("Content-Type", respType)
+			w.Write(c.resp)
 		})
 
 		if nil != err {
@@ -259,12 +351,46 @@ func TestDelete(t *testing.T) {
 		defer srv.Close()
 
 		got := &testObj{Name: "a"}
-		err = cli.Delete(context.TODO(), got, &types.Options{Params: map[string]string{"dryRun": "false"}})
+		status, err := cli.Delete(context.TODO(), got, c.option)
 
 		if nil != err {
 			t.Errorf("unexpected error when deleting %q: %v", c.name, err)
 			continue
 		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Delete(%q) want: %v\ngot: %v", c.name, c.want, got)
+		}
+		gotStatus := ""
+		if nil != status {
+			gotStatus = status.Status
+		}
+		if gotStatus != c.wantStatus {
+			t.Errorf("Delete(%q) got status %q. wanted %q", c.name, gotStatus, c.wantStatus)
+		}
+	}
+}
+
+func TestDeleteCollection(t *testing.T) {
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/test" || r.URL.Query().Get("labelSelector") != "app=a" {
+			t.Errorf("DeleteCollection got %s %s. wanted DELETE /test?labelSelector=app=a", r.Method, r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(getJSONList(getJSON("a", "1"), getJSON("b", "2")))
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	got := &testObjList{}
+	status, err := cli.DeleteCollection(context.TODO(), got, &types.Options{Params: map[string]string{"labelSelector": "app=a"}})
+	if nil != err || nil != status {
+		t.Fatalf("DeleteCollection got status %v and error %v", status, err)
+	}
+	want := &testObjList{Items: []testObj{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DeleteCollection want: %v\ngot: %v", want, got)
 	}
 }
 
@@ -396,11 +522,12 @@ func TestUpdate(t *testing.T) {
 
 func TestPatch(t *testing.T) {
 	cases := []struct {
-		name  string
-		path  string
-		patch []byte
-		resp  []byte
-		want  Object
+		name   string
+		object string
+		path   string
+		patch  []byte
+		resp   []byte
+		want   Object
 	}{
 		{
 			name:  "normal_patch",
@@ -409,6 +536,14 @@ func TestPatch(t *testing.T) {
 			resp:  getJSON("a", "b1"),
 			want:  &testObj{Name: "a", ID: "b1"},
 		},
+		{
+			name:   "type_link_patch",
+			object: "a",
+			path:   "/test?dryRun=All",
+			patch:  []byte(`{"id":"b1"}`),
+			resp:   getJSON("a", "b1"),
+			want:   &testObj{Name: "a", ID: "b1"},
+		},
 	}
 
 	for _, c := range cases {
@@ -427,6 +562,10 @@ func TestPatch(t *testing.T) {
 				t.Errorf("Patch(%q) got path %s. wanted %s", c.name, r.URL.Path, path.Path)
 			}
 
+			if !reflect.DeepEqual(r.URL.Query(), path.Query()) {
+				t.Errorf("Patch(%q) got query %v. wanted %v", c.name, r.URL.Query(), path.Query())
+			}
+
 			content := r.Header.Get("Content-Type")
 			if content != string(types2.StrategicMergePatchType) {
 				t.Errorf("Patch(%q) got Content-Type %s. wanted %s", c.name, content, types2.StrategicMergePatchType)
@@ -459,8 +598,8 @@ func TestPatch(t *testing.T) {
 			params[k] = values[0]
 		}
 
-		got := &testObj{}
-		err = cli.Patch(context.TODO(), got, ConstantPatch(types2.StrategicMergePatchType, c.patch))
+		got := &testObj{Name: c.object}
+		err = cli.Patch(context.TODO(), got, ConstantPatch(types2.StrategicMergePatchType, c.patch), &types.Options{Params: params})
 
 		if nil != err {
 			t.Errorf("unexpected error when patching %q: %v", c.name, err)
@@ -472,3 +611,156 @@ func TestPatch(t *testing.T) {
 		}
 	}
 }
+
+func TestWatch(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		resp []byte
+		want []Event
+	}{
+		{
+			name: "normal_watch",
+			path: "/test",
+			resp: []byte(`{"type":"ADDED","object":{"name":"a","id":"b"}}
+{"type":"MODIFIED","object":{"name":"a","id":"b1"}}
+{"type":"DELETED","object":{"name":"a","id":"b1"}}
+`),
+			want: []Event{
+				{Type: http2.Added, Object: &testObj{Name: "a", ID: "b"}},
+				{Type: http2.Modified, Object: &testObj{Name: "a", ID: "b1"}},
+				{Type: http2.Deleted, Object: &testObj{Name: "a", ID: "b1"}},
+			},
+		},
+		{
+			name: "error_watch",
+			path: "/test",
+			resp: []byte(`{"type":"ERROR","object":{"kind":"Status","status":"Failure","message":"too old","reason":"Expired","code":410}}`),
+			want: []Event{
+				{Type: http2.Error, Err: &apiError.StatusError{ErrStatus: metav1.Status{
+					TypeMeta: metav1.TypeMeta{Kind: "Status"},
+					Status:   metav1.StatusFailure,
+					Message:  "too old",
+					Reason:   metav1.StatusReasonExpired,
+					Code:     410,
+				}}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" {
+				t.Errorf("Watch(%q) got HTTP method %s. wanted GET", c.name, r.Method)
+			}
+
+			if r.URL.Path != c.path {
+				t.Errorf("Watch(%q) got path %s. wanted %s", c.name, r.URL.Path, c.path)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(c.resp)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		events, err := cli.Watch(context.TODO(), &testObj{}, nil)
+
+		if nil != err {
+			t.Errorf("unexpected error when watching %q: %v", c.name, err)
+			continue
+		}
+
+		got := []Event{}
+		for e := range events {
+			got = append(got, e)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Watch(%q) want: %v\ngot: %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name  string
+		patch Patch
+		path  string
+		query url.Values
+		resp  []byte
+		want  Object
+	}{
+		{
+			name:  "apply",
+			patch: Apply("kube-rest", false),
+			path:  "/test/a",
+			query: url.Values{"fieldManager": []string{"kube-rest"}},
+			resp:  getJSON("a", "b1"),
+			want:  &testObj{Name: "a", ID: "b1"},
+		},
+		{
+			name:  "force_apply",
+			patch: Apply("kube-rest", true),
+			path:  "/test/a",
+			query: url.Values{"fieldManager": []string{"kube-rest"}, "force": []string{"true"}},
+			resp:  getJSON("a", "b1"),
+			want:  &testObj{Name: "a", ID: "b1"},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != "PATCH" {
+				t.Errorf("Apply(%q) got HTTP method %s. wanted PATCH", c.name, r.Method)
+			}
+
+			if r.URL.Path != c.path {
+				t.Errorf("Apply(%q) got path %s. wanted %s", c.name, r.URL.Path, c.path)
+			}
+
+			if !reflect.DeepEqual(r.URL.Query(), c.query) {
+				t.Errorf("Apply(%q) got query %v. wanted %v", c.name, r.URL.Query(), c.query)
+			}
+
+			content := r.Header.Get("Content-Type")
+			if content != string(types2.ApplyPatchType) {
+				t.Errorf("Apply(%q) got Content-Type %s. wanted %s", c.name, content, types2.ApplyPatchType)
+			}
+
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("Apply(%q) unexpected error reading body: %v", c.name, err)
+			}
+			if !reflect.DeepEqual(c.resp, data) {
+				t.Errorf("Apply(%q) got data %s. wanted %s", c.name, data, c.resp)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(c.resp)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		got := &testObj{Name: "a", ID: "b1"}
+		err = cli.Patch(context.TODO(), got, c.patch, nil)
+
+		if nil != err {
+			t.Errorf("unexpected error when applying %q: %v", c.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Apply(%q) want: %v\ngot: %v", c.name, c.want, got)
+		}
+	}
+}
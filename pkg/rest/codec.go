@@ -0,0 +1,333 @@
+package rest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Codec knows how to encode and decode the data of rest objects.
+//
+// Codecs of other formats implement Codec on top of the library of their choice and are
+// passed to NewForInterface or NewForConfigWithCodecs.
+type Codec interface {
+	// ContentType is the media type of the encoded data.
+	ContentType() string
+	// Encode encodes v into bytes.
+	Encode(v interface{}) ([]byte, error)
+	// Decode decodes data into v, v must be a pointer.
+	Decode(data []byte, v interface{}) error
+}
+
+// Encodable is an Object or an ObjectList that could be encoded with any Codec. Clients
+// with codecs encode and decode it with the negotiated codec instead of Data and Parse.
+//
+// The others are transcoded from and to the json of Data and Parse through interface{}
+// values, which requires a codec able to encode and decode them, unlike ProtobufCodec.
+type Encodable interface {
+	Encode(codec Codec) ([]byte, error)
+	Decode(codec Codec, data []byte) error
+}
+
+// JSONCodec encodes and decodes objects with encoding/json.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+// ContentType implements Codec.
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+// Encode implements Codec.
+func (jsonCodec) Encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode implements Codec.
+func (jsonCodec) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// YAMLCodec encodes and decodes objects as yaml, through their json encoding so that
+// their json tags apply. Multi-document data is only decoded into an ObjectList, as the
+// list of its documents.
+var YAMLCodec Codec = yamlCodec{}
+
+type yamlCodec struct{}
+
+// ContentType implements Codec.
+func (yamlCodec) ContentType() string {
+	return "application/yaml"
+}
+
+// Encode implements Codec.
+func (yamlCodec) Encode(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// Decode implements Codec.
+func (yamlCodec) Decode(data []byte, v interface{}) error {
+	documents, err := yamlDocuments(data)
+	if nil != err {
+		return err
+	}
+	switch len(documents) {
+	case 0:
+		return json.Unmarshal([]byte("null"), v)
+	case 1:
+		return json.Unmarshal(documents[0], v)
+	}
+	return fmt.Errorf("%d yaml documents can't be decoded into %T", len(documents), v)
+}
+
+// yamlDocuments returns the json of the non-empty documents of data.
+func yamlDocuments(data []byte) ([]json.RawMessage, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	documents := []json.RawMessage{}
+	for {
+		document, err := reader.Read()
+		if err == io.EOF {
+			return documents, nil
+		}
+		if nil != err {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		bt, err := yaml.YAMLToJSON(document)
+		if nil != err {
+			return nil, err
+		}
+		if string(bt) != "null" {
+			documents = append(documents, bt)
+		}
+	}
+}
+
+// yamlList returns the yaml data of a list as the json of a list object, i.e. {"items": [...]},
+// or as the json of its items if the list is a slice. The items are those of its only document
+// if it's already a list object or a sequence, or its documents otherwise.
+func yamlList(data []byte, slice bool) ([]byte, error) {
+	documents, err := yamlDocuments(data)
+	if nil != err {
+		return nil, err
+	}
+	items := documents
+	if len(documents) == 1 {
+		var list struct {
+			Items json.RawMessage `json:"items"`
+		}
+		var elements []json.RawMessage
+		if err := json.Unmarshal(documents[0], &list); nil == err && nil != list.Items {
+			if !slice {
+				return documents[0], nil
+			}
+			return list.Items, nil
+		} else if err := json.Unmarshal(documents[0], &elements); nil == err {
+			items = elements
+		}
+	}
+	if slice {
+		return json.Marshal(items)
+	}
+	return json.Marshal(map[string]interface{}{"items": items})
+}
+
+// isSlice returns whether list is a slice, rather than a list object.
+func isSlice(list interface{}) bool {
+	if u, ok := list.(interface{ underlying() interface{} }); ok {
+		list = u.underlying()
+	}
+	t := reflect.TypeOf(list)
+	return nil != t && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Slice
+}
+
+// ProtobufCodec encodes and decodes values with their own Marshal and Unmarshal methods,
+// like the types generated by gogo/protobuf.
+var ProtobufCodec Codec = protobufCodec{}
+
+type protobufCodec struct{}
+
+// ContentType implements Codec.
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+// Encode implements Codec.
+func (protobufCodec) Encode(v interface{}) ([]byte, error) {
+	if m, ok := v.(interface{ Marshal() ([]byte, error) }); ok {
+		return m.Marshal()
+	}
+	return nil, fmt.Errorf("%T is not a protobuf message", v)
+}
+
+// Decode implements Codec.
+func (protobufCodec) Decode(data []byte, v interface{}) error {
+	if m, ok := v.(interface{ Unmarshal([]byte) error }); ok {
+		return m.Unmarshal(data)
+	}
+	return fmt.Errorf("%T is not a protobuf message", v)
+}
+
+// MsgpackCodec encodes and decodes objects as MessagePack, falling back to their json tags
+// for the fields without msgpack tags. Map keys are encoded in order.
+var MsgpackCodec Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+// ContentType implements Codec.
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+// Encode implements Codec.
+func (msgpackCodec) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := msgpack.NewEncoder(&buf).UseJSONTag(true).SortMapKeys(true).Encode(v); nil != err {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode implements Codec.
+func (msgpackCodec) Decode(data []byte, v interface{}) error {
+	return msgpack.NewDecoder(bytes.NewReader(data)).UseJSONTag(true).Decode(v)
+}
+
+// CBORCodec encodes and decodes objects as CBOR, falling back to their json tags for the
+// fields without cbor tags. Map keys are encoded in the deterministic order of RFC 8949, and
+// maps decoded into interface{} values have string keys, so that they could be transcoded to json.
+var CBORCodec Codec = newCBORCodec()
+
+type cborCodec struct {
+	encMode cbor.EncMode
+	decMode cbor.DecMode
+}
+
+func newCBORCodec() cborCodec {
+	encMode, err := cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+	if nil != err {
+		panic(err)
+	}
+	decMode, err := cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}{})}.DecMode()
+	if nil != err {
+		panic(err)
+	}
+	return cborCodec{encMode: encMode, decMode: decMode}
+}
+
+// ContentType implements Codec.
+func (cborCodec) ContentType() string {
+	return "application/cbor"
+}
+
+// Encode implements Codec.
+func (c cborCodec) Encode(v interface{}) ([]byte, error) {
+	return c.encMode.Marshal(v)
+}
+
+// Decode implements Codec.
+func (c cborCodec) Decode(data []byte, v interface{}) error {
+	return c.decMode.Unmarshal(data, v)
+}
+
+// encode encodes obj with codec.
+func encode(codec Codec, obj Object) ([]byte, error) {
+	if e, ok := obj.(Encodable); ok {
+		return e.Encode(codec)
+	}
+	data, err := obj.Data()
+	if nil != err || isJSON(codec) {
+		return data, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); nil != err {
+		return nil, err
+	}
+	return codec.Encode(v)
+}
+
+// decode decodes data into target, an Object or an ObjectList, with codec.
+func decode(codec Codec, data []byte, target interface{ Parse([]byte) error }) error {
+	if _, ok := target.(ObjectList); ok && mediaTypeOf(codec) == YAMLCodec.ContentType() {
+		list, err := yamlList(data, isSlice(target))
+		if nil != err {
+			return err
+		}
+		data = list
+	}
+	if e, ok := target.(Encodable); ok {
+		return e.Decode(codec, data)
+	}
+	if isJSON(codec) {
+		return target.Parse(data)
+	}
+	var v interface{}
+	if err := codec.Decode(data, &v); nil != err {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if nil != err {
+		return err
+	}
+	return target.Parse(data)
+}
+
+// mediaTypeAliases are the media types used in the wild for registered ones.
+var mediaTypeAliases = map[string]string{
+	"application/x-yaml":      "application/yaml",
+	"text/yaml":               "application/yaml",
+	"text/x-yaml":             "application/yaml",
+	"application/x-msgpack":   "application/msgpack",
+	"application/vnd.msgpack": "application/msgpack",
+}
+
+// codecFor returns the codec of codecs for the media type of contentType, nil if none.
+func codecFor(codecs []Codec, contentType string) Codec {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if nil != err {
+		return nil
+	}
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		mediaType = alias
+	}
+	for _, codec := range codecs {
+		if mediaTypeOf(codec) == mediaType {
+			return codec
+		}
+	}
+	return nil
+}
+
+// accept returns the Accept header of codecs.
+func accept(codecs []Codec) string {
+	types := make([]string, 0, len(codecs))
+	for _, codec := range codecs {
+		types = append(types, codec.ContentType())
+	}
+	return strings.Join(types, ", ")
+}
+
+func isJSON(codec Codec) bool {
+	return mediaTypeOf(codec) == "application/json"
+}
+
+func mediaTypeOf(codec Codec) string {
+	mediaType, _, err := mime.ParseMediaType(codec.ContentType())
+	if nil != err {
+		return codec.ContentType()
+	}
+	return mediaType
+}
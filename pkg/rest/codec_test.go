@@ -0,0 +1,405 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alauda/kube-rest/pkg/config"
+	http2 "github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+// prefixCodec is a json codec with a prefix, standing for a format that isn't json.
+type prefixCodec struct{}
+
+func (prefixCodec) ContentType() string {
+	return "application/x-prefixed"
+}
+
+func (prefixCodec) Encode(v interface{}) ([]byte, error) {
+	bt, err := json.Marshal(v)
+	return append([]byte("prefixed:"), bt...), err
+}
+
+func (prefixCodec) Decode(data []byte, v interface{}) error {
+	if !bytes.HasPrefix(data, []byte("prefixed:")) {
+		return errors.New("not prefixed")
+	}
+	return json.Unmarshal(bytes.TrimPrefix(data, []byte("prefixed:")), v)
+}
+
+func mustEncode(codec Codec, v interface{}) []byte {
+	bt, err := codec.Encode(v)
+	if nil != err {
+		panic(err)
+	}
+	return bt
+}
+
+// protoUser is encoded as name:id by its own Marshal and Unmarshal, like a protobuf message.
+type protoUser struct {
+	Name string
+	ID   string
+}
+
+func (u *protoUser) GetName() string {
+	return u.Name
+}
+
+func (u *protoUser) Marshal() ([]byte, error) {
+	return []byte(u.Name + ":" + u.ID), nil
+}
+
+func (u *protoUser) Unmarshal(data []byte) error {
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return errors.New("invalid user")
+	}
+	u.Name, u.ID = parts[0], parts[1]
+	return nil
+}
+
+func TestCodecs(t *testing.T) {
+	cases := []struct {
+		name        string
+		codecs      []Codec
+		json        bool
+		respType    string
+		resp        []byte
+		do          func(Client) (interface{}, error)
+		contentType string
+		accept      string
+		body        string
+		want        interface{}
+	}{
+		{
+			name:     "transcoded",
+			codecs:   []Codec{prefixCodec{}, JSONCodec},
+			respType: "application/x-prefixed; charset=utf-8",
+			resp:     []byte(`prefixed:{"name":"a","id":"2"}`),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a", ID: "1"}
+				return obj, c.Update(context.TODO(), obj, nil)
+			},
+			contentType: "application/x-prefixed",
+			accept:      "application/x-prefixed, application/json",
+			body:        `prefixed:{"id":"1","name":"a"}`,
+			want:        &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "decoded_by_content_type",
+			codecs:   []Codec{prefixCodec{}, JSONCodec},
+			respType: "application/json",
+			resp:     getJSONList(getJSON("a", "1")),
+			do: func(c Client) (interface{}, error) {
+				list := &testObjList{}
+				return list, c.List(context.TODO(), list, nil)
+			},
+			accept: "application/x-prefixed, application/json",
+			want:   &testObjList{Items: []testObj{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "json_by_default",
+			json:     true,
+			respType: "application/json",
+			resp:     getJSON("a", "2"),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a"}
+				return obj, c.Create(context.TODO(), obj, nil)
+			},
+			contentType: "application/json",
+			accept:      "application/json",
+			body:        `{"name":"a","id":""}`,
+			want:        &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "no_codecs",
+			respType: "application/x-prefixed",
+			resp:     getJSON("a", "2"),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a"}
+				return obj, c.Create(context.TODO(), obj, nil)
+			},
+			body: `{"name":"a","id":""}`,
+			want: &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "yaml",
+			codecs:   []Codec{YAMLCodec},
+			respType: "text/yaml",
+			resp:     []byte("name: a\nid: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a", ID: "1"}
+				return obj, c.Update(context.TODO(), obj, nil)
+			},
+			contentType: "application/yaml",
+			accept:      "application/yaml",
+			body:        "id: \"1\"\nname: a\n",
+			want:        &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "yaml_multi_document_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("---\nname: a\nid: \"1\"\n---\nname: b\nid: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &testObjList{}
+				return list, c.List(context.TODO(), list, nil)
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}}},
+		},
+		{
+			name:     "yaml_one_document_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("---\nname: a\nid: \"1\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &testObjList{}
+				return list, c.List(context.TODO(), list, nil)
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "yaml_list_object",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("items:\n- name: a\n  id: \"1\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &testObjList{}
+				return list, c.List(context.TODO(), list, nil)
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "yaml_sequence_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("- name: a\n  id: \"1\"\n- name: b\n  id: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &testObjList{}
+				return list, c.List(context.TODO(), list, nil)
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}}},
+		},
+		{
+			name:     "yaml_sequence_typed_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("- name: a\n  id: \"1\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &typedUserList{}
+				return list, NewTypedClient[typedUser, typedUserList](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "yaml_sequence_slice_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("- name: a\n  id: \"1\"\n- name: b\n  id: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &[]typedUser{}
+				return list, NewTypedClient[typedUser, []typedUser](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &[]typedUser{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}},
+		},
+		{
+			name:     "yaml_documents_slice_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("name: a\nid: \"1\"\n---\nname: b\nid: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &[]typedUser{}
+				return list, NewTypedClient[typedUser, []typedUser](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &[]typedUser{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}},
+		},
+		{
+			name:     "yaml_list_object_slice_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("items:\n- name: a\n  id: \"1\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &[]typedUser{}
+				return list, NewTypedClient[typedUser, []typedUser](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &[]typedUser{{Name: "a", ID: "1"}},
+		},
+		{
+			name:     "yaml_empty_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("---\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &typedUserList{}
+				return list, NewTypedClient[typedUser, typedUserList](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &typedUserList{Items: []typedUser{}},
+		},
+		{
+			name:     "yaml_typed_list",
+			codecs:   []Codec{YAMLCodec},
+			respType: "application/yaml",
+			resp:     []byte("name: a\nid: \"1\"\n---\nname: b\nid: \"2\"\n"),
+			do: func(c Client) (interface{}, error) {
+				list := &typedUserList{}
+				return list, NewTypedClient[typedUser, typedUserList](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}}},
+		},
+		{
+			name:     "msgpack",
+			codecs:   []Codec{MsgpackCodec, JSONCodec},
+			respType: "application/x-msgpack",
+			resp:     mustEncode(MsgpackCodec, map[string]interface{}{"name": "a", "id": "2"}),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a", ID: "1"}
+				return obj, c.Update(context.TODO(), obj, nil)
+			},
+			contentType: MsgpackCodec.ContentType(),
+			body:        string(mustEncode(MsgpackCodec, map[string]interface{}{"name": "a", "id": "1"})),
+			want:        &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "msgpack_typed_list",
+			codecs:   []Codec{MsgpackCodec},
+			respType: MsgpackCodec.ContentType(),
+			resp:     mustEncode(MsgpackCodec, &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}}}),
+			do: func(c Client) (interface{}, error) {
+				list := &typedUserList{}
+				return list, NewTypedClient[typedUser, typedUserList](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "cbor",
+			codecs:   []Codec{CBORCodec, JSONCodec},
+			respType: "application/cbor",
+			resp:     mustEncode(CBORCodec, map[string]interface{}{"name": "a", "id": "2"}),
+			do: func(c Client) (interface{}, error) {
+				obj := &testObj{Name: "a", ID: "1"}
+				return obj, c.Update(context.TODO(), obj, nil)
+			},
+			contentType: CBORCodec.ContentType(),
+			body:        string(mustEncode(CBORCodec, map[string]interface{}{"name": "a", "id": "1"})),
+			want:        &testObj{Name: "a", ID: "2"},
+		},
+		{
+			name:     "cbor_typed_list",
+			codecs:   []Codec{CBORCodec},
+			respType: CBORCodec.ContentType(),
+			resp:     mustEncode(CBORCodec, &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}}}),
+			do: func(c Client) (interface{}, error) {
+				list := &typedUserList{}
+				return list, NewTypedClient[typedUser, typedUserList](c, typedUsers).List(context.TODO(), list, nil)
+			},
+			want: &typedUserList{Items: []typedUser{{Name: "a", ID: "1"}}},
+		},
+		{
+			name:     "protobuf",
+			codecs:   []Codec{ProtobufCodec},
+			respType: "application/x-protobuf",
+			resp:     []byte("a:2"),
+			do: func(c Client) (interface{}, error) {
+				users := NewTypedClient[protoUser, struct{}](c, Resource[protoUser]{Path: "/test"})
+				user := &protoUser{Name: "a", ID: "1"}
+				return user, users.Create(context.TODO(), user, nil)
+			},
+			contentType: "application/x-protobuf",
+			accept:      "application/x-protobuf",
+			body:        "a:1",
+			want:        &protoUser{Name: "a", ID: "2"},
+		},
+	}
+
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.Header.Get("Content-Type"); len(c.contentType) > 0 && got != c.contentType {
+				t.Errorf("Codecs(%q) got Content-Type %q. wanted %q", c.name, got, c.contentType)
+			}
+			if got := r.Header.Get("Accept"); len(c.accept) > 0 && got != c.accept {
+				t.Errorf("Codecs(%q) got Accept %q. wanted %q", c.name, got, c.accept)
+			}
+			if body, _ := ioutil.ReadAll(r.Body); len(c.body) > 0 && string(body) != c.body {
+				t.Errorf("Codecs(%q) got body %s. wanted %s", c.name, body, c.body)
+			}
+			w.Header().Set("Content-Type", c.respType)
+			w.Write(c.resp)
+		}))
+		defer srv.Close()
+		cfg, err := config.GetDefaultConfig(srv.URL)
+		if nil != err {
+			t.Errorf("unexpected error when creating config: %v", err)
+			continue
+		}
+		var cli Client
+		if c.json {
+			cli, err = NewForConfig(cfg)
+		} else {
+			cli, err = NewForConfigWithCodecs(cfg, c.codecs)
+		}
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		got, err := c.do(cli)
+		if nil != err {
+			t.Errorf("unexpected error %q: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Codecs(%q) want: %v\ngot: %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestYAMLCodecDecode(t *testing.T) {
+	obj := &testObj{}
+	if err := YAMLCodec.Decode([]byte("name: a\n---\nname: b\n"), obj); nil == err {
+		t.Errorf("YAMLCodec.Decode of several documents got %v. wanted an error", obj)
+	}
+	if err := YAMLCodec.Decode([]byte("---\nname: a\n"), obj); nil != err || obj.Name != "a" {
+		t.Errorf("YAMLCodec.Decode got %v, %v. wanted name a", obj, err)
+	}
+}
+
+func TestCodecHeadersUnderDefaults(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Content-Type")+" "+r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(getJSON("a", "1"))
+	}))
+	defer srv.Close()
+	cfg, err := config.GetDefaultConfig(srv.URL)
+	if nil != err {
+		t.Fatalf("unexpected error when creating config: %v", err)
+	}
+	cli, err := NewForConfig(cfg, http2.WithDefaultOption(types.WithHeader("Content-Type", "application/vnd.api+json")))
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+
+	if err := cli.Create(context.TODO(), &testObj{Name: "a"}, nil); nil != err {
+		t.Fatalf("unexpected error when creating: %v", err)
+	}
+	if err := cli.Update(context.TODO(), &testObj{Name: "a"}, types.WithHeader("Accept", "application/vnd.api+json")); nil != err {
+		t.Fatalf("unexpected error when updating: %v", err)
+	}
+	want := []string{
+		"application/vnd.api+json application/json",
+		"application/vnd.api+json application/vnd.api+json",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CodecHeaders want: %v\ngot: %v", want, got)
+	}
+}
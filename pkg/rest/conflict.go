@@ -0,0 +1,82 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRetry is the default backoff of RetryOnConflict, suitable for
+// conflicts between a few writers.
+var DefaultRetry = wait.Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   1.0,
+	Jitter:   0.1,
+}
+
+// RetryError is the error of the last attempt of RetryOnConflict.
+type RetryError struct {
+	// Attempts is the number of updates attempted.
+	Attempts int
+	Err      error
+}
+
+var _ apiError.APIStatus = &RetryError{}
+
+// Error implements error.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("update failed after %d attempt(s): %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Status implements apiError.APIStatus, it's the status of the error of the last attempt.
+func (e *RetryError) Status() metav1.Status {
+	var status apiError.APIStatus
+	if errors.As(e.Err, &status) {
+		return status.Status()
+	}
+	return apiError.NewInternalError(e.Err).Status()
+}
+
+// RetryOnConflict gets obj, applies mutate to it and updates it, until the update
+// doesn't fail with a Conflict or the steps of backoff are exhausted.
+//
+// The error of the last attempt is returned as a RetryError. An error returned by
+// mutate is returned as is, and stops retrying.
+func RetryOnConflict(ctx context.Context, c Client, obj Object, mutate func() error, backoff wait.Backoff) error {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	for attempt := 1; ; attempt++ {
+		if err := c.Get(ctx, obj, nil); nil != err {
+			return &RetryError{Attempts: attempt, Err: err}
+		}
+		if err := mutate(); nil != err {
+			return err
+		}
+		err := c.Update(ctx, obj, nil)
+		if nil == err {
+			return nil
+		}
+		if !apiError.IsConflict(err) || backoff.Steps <= 1 {
+			return &RetryError{Attempts: attempt, Err: err}
+		}
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{Attempts: attempt, Err: ctx.Err()}
+		}
+	}
+}
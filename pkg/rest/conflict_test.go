@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestRetryOnConflict(t *testing.T) {
+	cases := []struct {
+		name      string
+		conflicts int
+		steps     int
+		mutateErr error
+		attempts  int
+		updates   int
+		wantErr   bool
+	}{
+		{name: "no_conflict", conflicts: 0, steps: 3, attempts: 1, updates: 1},
+		{name: "resolved", conflicts: 2, steps: 3, attempts: 3, updates: 3},
+		{name: "exhausted", conflicts: 5, steps: 3, attempts: 3, updates: 3, wantErr: true},
+		{name: "mutate_failed", conflicts: 0, steps: 3, mutateErr: errors.New("invalid"), attempts: 1, wantErr: true},
+	}
+
+	for _, c := range cases {
+		version, updates := 0, 0
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "PUT" {
+				updates++
+				obj := testObj{}
+				json.NewDecoder(r.Body).Decode(&obj)
+				if obj.ID != strconv.Itoa(version) || updates <= c.conflicts {
+					version++
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+			}
+			w.Write(getJSON("a", strconv.Itoa(version)))
+		})
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+		defer srv.Close()
+
+		attempts := 0
+		obj := &testObj{Name: "a"}
+		err = RetryOnConflict(context.TODO(), cli, obj, func() error {
+			attempts++
+			if obj.ID != strconv.Itoa(version) {
+				t.Errorf("RetryOnConflict(%q) mutated stale object %s. wanted %d", c.name, obj.ID, version)
+			}
+			return c.mutateErr
+		}, wait.Backoff{Steps: c.steps, Duration: time.Millisecond})
+
+		if attempts != c.attempts || updates != c.updates {
+			t.Errorf("RetryOnConflict(%q) got %d attempts and %d updates. wanted %d and %d", c.name, attempts, updates, c.attempts, c.updates)
+		}
+		if (nil != err) != c.wantErr {
+			t.Errorf("RetryOnConflict(%q) got error %v", c.name, err)
+			continue
+		}
+		if nil != c.mutateErr && err != c.mutateErr {
+			t.Errorf("RetryOnConflict(%q) got error %v. wanted %v", c.name, err, c.mutateErr)
+		}
+		if retryErr, ok := err.(*RetryError); ok {
+			if retryErr.Attempts != c.attempts || !apiError.IsConflict(err) {
+				t.Errorf("RetryOnConflict(%q) got error %v. wanted a conflict after %d attempts", c.name, err, c.attempts)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package fake provides an in-memory rest.Client for unit tests.
+package fake
+
+import (
+	"context"
+	nethttp "net/http"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	fakehttp "github.com/alauda/kube-rest/pkg/http/fake"
+	"github.com/alauda/kube-rest/pkg/rest"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types2 "k8s.io/apimachinery/pkg/types"
+)
+
+var _ rest.Client = &Client{}
+
+// Client is a rest.Client tracking objects in memory by their SelfLink.
+//
+// Its actions are those a real client would send, so reactors can be added for
+// them the same way as for fakehttp.Client. A response returned by a reactor is
+// parsed into the object.
+type Client struct {
+	fakehttp.Fake
+	Tracker *fakehttp.Tracker
+}
+
+// NewClient creates a fake client tracking objects, it panics if the data of one of them fails.
+func NewClient(objects ...rest.Object) *Client {
+	tracker := fakehttp.NewTracker()
+	for _, obj := range objects {
+		data, err := obj.Data()
+		if nil != err {
+			panic(err)
+		}
+		tracker.Add(obj.SelfLink(), data)
+	}
+	return &Client{Tracker: tracker}
+}
+
+// invoke invokes the reactors for action, the data returned by react is parsed into obj.
+func (c *Client) invoke(obj interface{ Parse([]byte) error }, action fakehttp.Action, react func() ([]byte, error)) error {
+	resp, err := c.Invoke(action, func(action fakehttp.Action) (bool, *http.Response, error) {
+		data, err := react()
+		return true, &http.Response{StatusCode: nethttp.StatusOK, Body: data}, err
+	})
+	if nil != err {
+		return err
+	}
+	if nil == resp || len(resp.Body) == 0 {
+		return nil
+	}
+	return obj.Parse(resp.Body)
+}
+
+// Create implements rest.Client
+func (c *Client) Create(ctx context.Context, obj rest.Object, option types.Option) error {
+	data, err := obj.Data()
+	if nil != err {
+		return err
+	}
+	action := fakehttp.NewAction(nethttp.MethodPost, obj.TypeLink(), "", data, option)
+	return c.invoke(obj, action, func() ([]byte, error) {
+		return data, c.Tracker.Create(obj.SelfLink(), data)
+	})
+}
+
+// Update implements rest.Client
+func (c *Client) Update(ctx context.Context, obj rest.Object, option types.Option) error {
+	data, err := obj.Data()
+	if nil != err {
+		return err
+	}
+	action := fakehttp.NewAction(nethttp.MethodPut, obj.SelfLink(), "", data, option)
+	return c.invoke(obj, action, func() ([]byte, error) {
+		return data, c.Tracker.Update(obj.SelfLink(), data)
+	})
+}
+
+// Get implements rest.Client
+func (c *Client) Get(ctx context.Context, obj rest.Object, option types.Option) error {
+	action := fakehttp.NewAction(nethttp.MethodGet, obj.SelfLink(), "", nil, option)
+	return c.invoke(obj, action, func() ([]byte, error) {
+		return c.Tracker.Get(obj.SelfLink())
+	})
+}
+
+// List implements rest.Client
+func (c *Client) List(ctx context.Context, obj rest.ObjectList, option types.Option) error {
+	action := fakehttp.NewAction(nethttp.MethodGet, obj.TypeLink(), "", nil, option)
+	resp, err := c.Invoke(action, func(action fakehttp.Action) (bool, *http.Response, error) {
+		data, err := c.Tracker.List(obj.TypeLink())
+		return true, &http.Response{StatusCode: nethttp.StatusOK, Body: data}, err
+	})
+	if nil != err {
+		return err
+	}
+	if nil == resp {
+		return nil
+	}
+	return obj.Parse(resp.Body)
+}
+
+// Delete implements rest.Client, the deleted object is parsed into obj.
+func (c *Client) Delete(ctx context.Context, obj rest.Object, option types.Option) (*metav1.Status, error) {
+	action := fakehttp.NewAction(nethttp.MethodDelete, obj.SelfLink(), "", nil, option)
+	return nil, c.invoke(obj, action, func() ([]byte, error) {
+		return c.Tracker.Delete(obj.SelfLink())
+	})
+}
+
+// DeleteCollection implements rest.Client, the deleted objects are parsed into list.
+// Selectors aren't supported, the whole collection is deleted.
+func (c *Client) DeleteCollection(ctx context.Context, list rest.ObjectList, option types.Option) (*metav1.Status, error) {
+	action := fakehttp.NewAction(nethttp.MethodDelete, list.TypeLink(), "", nil, option)
+	return nil, c.invoke(list, action, func() ([]byte, error) {
+		return c.Tracker.DeleteCollection(list.TypeLink())
+	})
+}
+
+// Patch implements rest.Client, the action is at the path the patch is sent to, like
+// rest.Client does, and the patch is applied to the object at the SelfLink of obj.
+func (c *Client) Patch(ctx context.Context, obj rest.Object, patch rest.Patch, option types.Option) error {
+	data, err := patch.Data(obj)
+	if nil != err {
+		return err
+	}
+	options := []types.Option{option}
+	if patchOption, ok := patch.(types.Option); ok {
+		options = []types.Option{patchOption, option}
+	}
+	absPath := obj.TypeLink()
+	if patch.Type() == types2.ApplyPatchType {
+		absPath = obj.SelfLink()
+	}
+	action := fakehttp.NewAction(nethttp.MethodPatch, absPath, patch.Type(), data, options...)
+	return c.invoke(obj, action, func() ([]byte, error) {
+		return c.Tracker.Patch(obj.SelfLink(), patch.Type(), data)
+	})
+}
+
+// Watch implements rest.Client, it streams the changes of the collection of obj.
+// If a reactor handles the watch without an error, the watch ends right away.
+func (c *Client) Watch(ctx context.Context, obj rest.Object, option types.Option) (<-chan rest.Event, error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	var raw <-chan http.Event
+	action := fakehttp.NewAction(fakehttp.Watch, obj.TypeLink(), "", nil, option)
+	_, err := c.Invoke(action, func(action fakehttp.Action) (bool, *http.Response, error) {
+		raw = c.Tracker.Watch(ctx, obj.TypeLink())
+		return true, nil, nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	events := make(chan rest.Event)
+	if nil == raw {
+		// a reactor handled the watch, which ends right away
+		close(events)
+		return events, nil
+	}
+	go func() {
+		defer close(events)
+		for e := range raw {
+			event := rest.Event{Type: e.Type, Object: rest.NewObject(obj)}
+			if err := event.Object.Parse(e.Object); nil != err {
+				event = rest.Event{Type: http.Error, Err: err}
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,211 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	fakehttp "github.com/alauda/kube-rest/pkg/http/fake"
+	"github.com/alauda/kube-rest/pkg/rest"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type testObj struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (t *testObj) TypeLink(segments ...string) string {
+	return "/test"
+}
+
+func (t *testObj) SelfLink(segments ...string) string {
+	return path.Join("/test", t.Name)
+}
+
+func (t *testObj) Data() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+func (t *testObj) Parse(bt []byte) error {
+	clone := new(testObj)
+	if err := json.Unmarshal(bt, clone); nil != err {
+		return err
+	}
+	*t = *clone
+	return nil
+}
+
+type testObjList struct {
+	Items []testObj `json:"items"`
+}
+
+func (t *testObjList) TypeLink() string {
+	return "/test"
+}
+
+func (t *testObjList) Parse(bt []byte) error {
+	clone := new(testObjList)
+	if err := json.Unmarshal(bt, clone); nil != err {
+		return err
+	}
+	*t = *clone
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.TODO()
+	cli := NewClient(&testObj{Name: "a", ID: "1"})
+
+	obj := &testObj{Name: "a"}
+	if err := cli.Get(ctx, obj, nil); nil != err || obj.ID != "1" {
+		t.Errorf("Get got %v, %v. wanted id 1", obj, err)
+	}
+	if err := cli.Get(ctx, &testObj{Name: "b"}, nil); !apiError.IsNotFound(err) {
+		t.Errorf("Get got error %v. wanted not found", err)
+	}
+	if err := cli.Create(ctx, &testObj{Name: "b", ID: "2"}, nil); nil != err {
+		t.Errorf("unexpected error when creating: %v", err)
+	}
+	if err := cli.Create(ctx, &testObj{Name: "b"}, nil); http.StatusCode(err) != nethttp.StatusConflict {
+		t.Errorf("Create got error %v. wanted conflict", err)
+	}
+	if err := cli.Patch(ctx, obj, rest.ConstantPatch("application/merge-patch+json", []byte(`{"id":"3"}`)), nil); nil != err || obj.ID != "3" {
+		t.Errorf("Patch got %v, %v. wanted id 3", obj, err)
+	}
+	if err := cli.Update(ctx, &testObj{Name: "b", ID: "4"}, nil); nil != err {
+		t.Errorf("unexpected error when updating: %v", err)
+	}
+	list := &testObjList{}
+	if err := cli.List(ctx, list, nil); nil != err {
+		t.Errorf("unexpected error when listing: %v", err)
+	}
+	want := []testObj{{Name: "a", ID: "3"}, {Name: "b", ID: "4"}}
+	if !reflect.DeepEqual(list.Items, want) {
+		t.Errorf("List want: %v\ngot: %v", want, list.Items)
+	}
+	if _, err := cli.Delete(ctx, obj, nil); nil != err || cli.Tracker.Has("/test/a") || obj.ID != "3" {
+		t.Errorf("Delete got %v, %v. wanted /test/a deleted", obj, err)
+	}
+	if _, err := cli.DeleteCollection(ctx, list, nil); nil != err || cli.Tracker.Has("/test/b") || len(list.Items) != 1 {
+		t.Errorf("DeleteCollection got %v, %v. wanted /test/b deleted", list.Items, err)
+	}
+
+	verbs := []string{}
+	for _, action := range cli.Actions() {
+		verbs = append(verbs, action.Verb+" "+action.AbsPath)
+	}
+	wantVerbs := []string{"GET /test/a", "GET /test/b", "POST /test", "POST /test", "PATCH /test", "PUT /test/b", "GET /test", "DELETE /test/a", "DELETE /test"}
+	if !reflect.DeepEqual(verbs, wantVerbs) {
+		t.Errorf("Actions want: %v\ngot: %v", wantVerbs, verbs)
+	}
+}
+
+func TestClientReactors(t *testing.T) {
+	ctx := context.TODO()
+	cli := NewClient(&testObj{Name: "a", ID: "1"})
+	cli.AddReactor(nethttp.MethodPut, "*", fakehttp.ErrorReaction(fakehttp.NewError(nethttp.MethodPut, nethttp.StatusConflict, "conflict")))
+	cli.PrependReactor(nethttp.MethodGet, "/test/a", fakehttp.DelayReaction(10*time.Millisecond))
+	cli.PrependReactor(nethttp.MethodGet, "/test/b", func(action fakehttp.Action) (bool, *http.Response, error) {
+		return true, &http.Response{StatusCode: nethttp.StatusOK, Body: []byte(`{"name":"b","id":"2"}`)}, nil
+	})
+
+	start := time.Now()
+	obj := &testObj{Name: "a"}
+	if err := cli.Get(ctx, obj, nil); nil != err || obj.ID != "1" {
+		t.Errorf("Get got %v, %v. wanted id 1", obj, err)
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Errorf("Get got no delay")
+	}
+	obj = &testObj{Name: "b"}
+	if err := cli.Get(ctx, obj, nil); nil != err || obj.ID != "2" {
+		t.Errorf("Get got %v, %v. wanted id 2 by the reactor", obj, err)
+	}
+	if err := cli.Update(ctx, &testObj{Name: "a", ID: "3"}, nil); !apiError.IsConflict(err) {
+		t.Errorf("Update got error %v. wanted conflict", err)
+	}
+	if data, _ := cli.Tracker.Get("/test/a"); string(data) != `{"name":"a","id":"1"}` {
+		t.Errorf("Update got %s updated. wanted it failed by the reactor", data)
+	}
+}
+
+func TestClientWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cli := NewClient()
+	events, err := cli.Watch(ctx, &testObj{}, nil)
+	if nil != err {
+		t.Fatalf("unexpected error when watching: %v", err)
+	}
+	cli.Create(ctx, &testObj{Name: "a", ID: "1"}, nil)
+	cli.Delete(ctx, &testObj{Name: "a"}, nil)
+
+	want := []rest.Event{
+		{Type: http.Added, Object: &testObj{Name: "a", ID: "1"}},
+		{Type: http.Deleted, Object: &testObj{Name: "a", ID: "1"}},
+	}
+	for i := range want {
+		if got := <-events; !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("Watch got event %d %#v. wanted %#v", i, got, want[i])
+		}
+	}
+}
+
+func TestClientWatchReactor(t *testing.T) {
+	cli := NewClient()
+	cli.AddReactor(fakehttp.Watch, "*", func(action fakehttp.Action) (bool, *http.Response, error) {
+		return true, nil, nil
+	})
+	events, err := cli.Watch(context.TODO(), &testObj{}, nil)
+	if nil != err || nil == events {
+		t.Fatalf("Watch got %v, %v. wanted a channel", events, err)
+	}
+	select {
+	case e, ok := <-events:
+		if ok {
+			t.Errorf("Watch got event %#v. wanted closed", e)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Watch handled by a reactor blocked")
+	}
+}
+
+func TestRestClientOverFakeHTTP(t *testing.T) {
+	cli := rest.NewForInterface(fakehttp.NewClient(nil), rest.JSONCodec)
+	list := &testObjList{}
+	if err := cli.List(context.TODO(), list, nil); nil != err {
+		t.Fatalf("List of an empty collection got error %v", err)
+	}
+	if want := (&testObjList{Items: []testObj{}}); !reflect.DeepEqual(list, want) {
+		t.Errorf("List want: %v\ngot: %v", want, list)
+	}
+
+	if err := cli.Create(context.TODO(), &testObj{Name: "a", ID: "1"}, nil); nil != err {
+		t.Fatalf("unexpected error when creating: %v", err)
+	}
+	if err := cli.List(context.TODO(), list, nil); nil != err || len(list.Items) != 1 {
+		t.Errorf("List got %v, %v. wanted 1 item", list, err)
+	}
+	if err := cli.Get(context.TODO(), &testObj{Name: "b"}, nil); !apiError.IsNotFound(err) {
+		t.Errorf("Get of a missing object got error %v. wanted not found", err)
+	}
+}
+
+func TestClientApply(t *testing.T) {
+	cli := NewClient()
+	obj := &testObj{Name: "a", ID: "1"}
+	if err := cli.Patch(context.TODO(), obj, rest.Apply("kube-rest", false), nil); nil != err || !cli.Tracker.Has("/test/a") {
+		t.Fatalf("Apply got %v, %v. wanted /test/a created", obj, err)
+	}
+	action := cli.Actions()[0]
+	if action.AbsPath != "/test/a" || action.Query.Get("fieldManager") != "kube-rest" {
+		t.Errorf("Apply got action %s %s?%s. wanted /test/a?fieldManager=kube-rest", action.Verb, action.AbsPath, action.Query.Encode())
+	}
+}
@@ -3,8 +3,10 @@ package rest
 import (
 	"context"
 
+	"github.com/alauda/kube-rest/pkg/http"
 	"github.com/alauda/kube-rest/pkg/types"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types2 "k8s.io/apimachinery/pkg/types"
 )
 
@@ -17,6 +19,12 @@ type Object interface {
 	Parse(bt []byte) error
 }
 
+// Prototype is an Object that knows how to create new, empty instances of itself.
+// Objects that don't implement it are instantiated with reflection.
+type Prototype interface {
+	New() Object
+}
+
 // ObjectList is the object list entity for a rest request.
 // It knows how to get request url, and deepcopy the objects.
 type ObjectList interface {
@@ -37,7 +45,7 @@ type Reader interface {
 	// Get retrieves an obj for the given object key from the rest object.
 	// obj must be a struct pointer so that obj can be updated with the response
 	// returned by the Server.
-	Get(ctx context.Context, obj Object) error
+	Get(ctx context.Context, obj Object, option types.Option) error
 
 	// List retrieves list of objects for a given namespace and list options. On a
 	// successful call, Items field in the list will be populated with the
@@ -50,20 +58,46 @@ type Writer interface {
 	// Create saves the object obj in the rest object.
 	Create(ctx context.Context, obj Object, option types.Option) error
 
-	// Delete deletes the given obj from rest object.
-	Delete(ctx context.Context, obj Object, option types.Option) error
+	// Delete deletes the given obj from rest object. obj is updated with the final
+	// object if the server returns it, e.g. when the deletion waits for finalizers,
+	// otherwise the Status returned by the server is returned, nil if there is none.
+	Delete(ctx context.Context, obj Object, option types.Option) (*metav1.Status, error)
+
+	// DeleteCollection deletes the objects at the TypeLink of list selected by option,
+	// e.g. with a label selector. list is updated with the deleted objects if the
+	// server returns them, otherwise the Status returned by the server is returned.
+	DeleteCollection(ctx context.Context, list ObjectList, option types.Option) (*metav1.Status, error)
 
 	// Update updates the given obj in the rest object. obj must be a
 	// struct pointer so that obj can be updated with the content returned by the Server.
 	Update(ctx context.Context, obj Object, option types.Option) error
 
-	// Update updates the given obj in the rest object. obj must be a
-	// struct pointer so that obj can be updated with the content returned by the Server.
-	Patch(ctx context.Context, obj Object, patch Patch) error
+	// Patch patches the given obj with patch, at its TypeLink, or at its SelfLink if patch is
+	// an apply patch. obj must be a struct pointer so that obj can be updated with the content
+	// returned by the Server.
+	Patch(ctx context.Context, obj Object, patch Patch, option types.Option) error
+}
+
+// Event represents a single event to a watched object.
+type Event struct {
+	Type http.EventType
+	// Object is the changed object, it's nil if Type is http.Error.
+	Object Object
+	// Err is the error reported by the server if Type is http.Error.
+	Err error
+}
+
+// Watcher knows how to watch rest objects.
+type Watcher interface {
+	// Watch watches the objects at the TypeLink of obj. obj is used as a prototype:
+	// every event carries a new instance of it. The returned channel is closed
+	// when ctx is done or the server ends the watch.
+	Watch(ctx context.Context, obj Object, option types.Option) (<-chan Event, error)
 }
 
 // Client knows how to perform CRUD operations on Object
 type Client interface {
 	Reader
 	Writer
+	Watcher
 }
@@ -0,0 +1,322 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+// DefaultLimitParam is the query parameter carrying the page size.
+const DefaultLimitParam = "limit"
+
+// Continuation extracts the query parameters requesting the next page from
+// the body and header of a list response. It returns empty values on the last page.
+type Continuation func(body []byte, header nethttp.Header) (url.Values, error)
+
+// KubernetesContinuation follows the metadata.continue token of kubernetes style lists.
+var KubernetesContinuation = CursorContinuation("metadata.continue", "continue")
+
+// CursorContinuation follows the cursor at the dot separated field of a json list,
+// sending it as the query parameter param.
+func CursorContinuation(field, param string) Continuation {
+	fields := strings.Split(field, ".")
+	return func(body []byte, header nethttp.Header) (url.Values, error) {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); nil != err {
+			return nil, err
+		}
+		for _, f := range fields {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			value = m[f]
+		}
+		var cursor string
+		switch v := value.(type) {
+		case nil:
+		case string:
+			cursor = v
+		case float64:
+			cursor = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("unexpected cursor %v at %q", v, field)
+		}
+		if len(cursor) == 0 {
+			return nil, nil
+		}
+		return url.Values{param: []string{cursor}}, nil
+	}
+}
+
+// LinkContinuation follows the query of the Link header with rel="next" (RFC 8288).
+func LinkContinuation(body []byte, header nethttp.Header) (url.Values, error) {
+	for _, value := range header["Link"] {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) != 2 || !strings.EqualFold(kv[0], "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(kv[1], `"`)) {
+					if strings.EqualFold(rel, "next") {
+						next, err := url.Parse(strings.Trim(target, "<>"))
+						if nil != err {
+							return nil, err
+						}
+						return next.Query(), nil
+					}
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ListPrototype is an ObjectList that knows how to create new, empty instances of itself.
+// Lists that don't implement it are instantiated with reflection.
+type ListPrototype interface {
+	New() ObjectList
+}
+
+// ListMerger is an ObjectList that knows how to append the items of another page to itself.
+// Lists that don't implement it must be a struct with an Items slice, or a slice.
+type ListMerger interface {
+	Merge(page ObjectList) error
+}
+
+// ItemLister is an ObjectList that knows how to return its items as Objects.
+// Lists that don't implement it must be a struct with an Items slice, or a slice,
+// of Objects or of values whose pointers are Objects.
+type ItemLister interface {
+	Objects() []Object
+}
+
+// ListObjects returns the items of list as Objects.
+func ListObjects(list ObjectList) ([]Object, error) {
+	if l, ok := list.(ItemLister); ok {
+		return l.Objects(), nil
+	}
+	items, err := listItems(list)
+	if nil != err {
+		return nil, err
+	}
+	objects := make([]Object, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		item := items.Index(i)
+		if obj, ok := item.Interface().(Object); ok {
+			objects = append(objects, obj)
+		} else if obj, ok := item.Addr().Interface().(Object); ok {
+			objects = append(objects, obj)
+		} else {
+			return nil, fmt.Errorf("item %s of list %T is not an Object", item.Type(), list)
+		}
+	}
+	return objects, nil
+}
+
+// Pager lists a collection page by page following its continuation.
+//
+// Continuations reading the header of the responses, like LinkContinuation, need a
+// Client created by this package, the header of the others' responses is empty.
+type Pager struct {
+	Client Client
+	// Continuation extracts the next page from a response, KubernetesContinuation if nil.
+	Continuation Continuation
+	// PageSize is the maximum number of objects per page, replacing the limit of the
+	// option. Zero leaves it to the option, e.g. the Limit of types.ListOptions, or to
+	// the server.
+	PageSize int64
+	// LimitParam is the query parameter of PageSize, DefaultLimitParam if empty.
+	LimitParam string
+}
+
+// Iterate returns an iterator over the pages of the collection of newList,
+// option is applied to every page request.
+func (p *Pager) Iterate(newList func() ObjectList, option types.Option) *ListIterator {
+	return &ListIterator{pager: p, newList: newList, option: option}
+}
+
+// ListAll lists all pages of the collection and merges them into list.
+func (p *Pager) ListAll(ctx context.Context, list ObjectList, option types.Option) error {
+	it := p.Iterate(func() ObjectList { return newObjectList(list) }, option)
+	first := true
+	for it.Next(ctx) {
+		if first {
+			if err := list.Parse(it.body); nil != err {
+				return err
+			}
+			first = false
+			continue
+		}
+		if err := mergeList(list, it.Item()); nil != err {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+func (p *Pager) continuation() Continuation {
+	if nil == p.Continuation {
+		return KubernetesContinuation
+	}
+	return p.Continuation
+}
+
+// list lists a page and returns the header of the response, if the client tells it.
+func (p *Pager) list(ctx context.Context, page ObjectList, option types.Option) (nethttp.Header, error) {
+	c, ok := p.Client.(*client)
+	if !ok {
+		return nethttp.Header{}, p.Client.List(ctx, page, option)
+	}
+	resp, err := c.list(ctx, page, option)
+	if nil != err {
+		return nil, err
+	}
+	return resp.Header, nil
+}
+
+func (p *Pager) pageParams() url.Values {
+	params := url.Values{}
+	if p.PageSize > 0 {
+		limit := p.LimitParam
+		if len(limit) == 0 {
+			limit = DefaultLimitParam
+		}
+		params.Set(limit, strconv.FormatInt(p.PageSize, 10))
+	}
+	return params
+}
+
+// ListIterator iterates over the pages of a collection:
+//
+//	it := pager.Iterate(newList, option)
+//	for it.Next(ctx) {
+//		page := it.Item()
+//	}
+//	if err := it.Err(); nil != err {
+//	}
+type ListIterator struct {
+	pager   *Pager
+	newList func() ObjectList
+	option  types.Option
+
+	next url.Values
+	done bool
+	item ObjectList
+	body []byte
+	err  error
+}
+
+// Next fetches the next page with ctx, it returns false when all pages have been
+// listed or an error occurred.
+func (it *ListIterator) Next(ctx context.Context) bool {
+	if it.done || nil != it.err {
+		return false
+	}
+	params := it.pager.pageParams()
+	for k, v := range it.next {
+		params[k] = v
+	}
+	page := &pageList{ObjectList: it.newList()}
+	// the parameters of the page replace those of the same keys set by option
+	header, err := it.pager.list(ctx, page, types.Merge(it.option, &types.Options{Query: params}))
+	if nil != err {
+		it.err = err
+		return false
+	}
+	it.item, it.body = page.ObjectList, page.body
+
+	next, err := it.pager.continuation()(page.body, header)
+	if nil != err {
+		it.err = err
+		return false
+	}
+	it.next, it.done = next, len(next) == 0
+	return true
+}
+
+// Item returns the current page.
+func (it *ListIterator) Item() ObjectList {
+	return it.item
+}
+
+// Err returns the error stopped the iteration.
+func (it *ListIterator) Err() error {
+	return it.err
+}
+
+// pageList captures the raw data of a page.
+type pageList struct {
+	ObjectList
+	body []byte
+}
+
+func (p *pageList) Parse(bt []byte) error {
+	p.body = bt
+	return p.ObjectList.Parse(bt)
+}
+
+// newObjectList creates a new, empty instance of list.
+func newObjectList(list ObjectList) ObjectList {
+	if p, ok := list.(ListPrototype); ok {
+		return p.New()
+	}
+	t := reflect.TypeOf(list)
+	if t.Kind() == reflect.Ptr {
+		return reflect.New(t.Elem()).Interface().(ObjectList)
+	}
+	return reflect.New(t).Elem().Interface().(ObjectList)
+}
+
+// listItems returns the addressable items slice of list.
+func listItems(list interface{}) (reflect.Value, error) {
+	if u, ok := list.(interface{ underlying() interface{} }); ok {
+		list = u.underlying()
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}, fmt.Errorf("list %T is not a pointer", list)
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Struct {
+		v = v.FieldByName("Items")
+	}
+	if v.Kind() != reflect.Slice {
+		return reflect.Value{}, fmt.Errorf("list %T has no items", list)
+	}
+	return v, nil
+}
+
+// mergeList appends the items of page to list.
+func mergeList(list, page ObjectList) error {
+	if m, ok := list.(ListMerger); ok {
+		return m.Merge(page)
+	}
+	dst, err := listItems(list)
+	if nil != err {
+		return err
+	}
+	src, err := listItems(page)
+	if nil != err {
+		return err
+	}
+	if dst.Type() != src.Type() {
+		return errors.New("pages of different list types")
+	}
+	dst.Set(reflect.AppendSlice(dst, src))
+	return nil
+}
@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+func TestPagerListAll(t *testing.T) {
+	cases := []struct {
+		name         string
+		continuation Continuation
+		pages        map[string]func(w http.ResponseWriter)
+		want         ObjectList
+	}{
+		{
+			name: "kubernetes_continue",
+			pages: map[string]func(w http.ResponseWriter){
+				"filter=a&limit=1": func(w http.ResponseWriter) {
+					w.Write([]byte(`{"metadata":{"continue":"t1"},"items":[{"name":"a","id":"1"}]}`))
+				},
+				"continue=t1&filter=a&limit=1": func(w http.ResponseWriter) {
+					w.Write([]byte(`{"metadata":{},"items":[{"name":"b","id":"2"}]}`))
+				},
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}}},
+		},
+		{
+			name:         "link_next",
+			continuation: LinkContinuation,
+			pages: map[string]func(w http.ResponseWriter){
+				"filter=a&limit=1": func(w http.ResponseWriter) {
+					w.Header().Set("Link", `</test?filter=a&limit=1&page=2>; rel="next", </test?page=3>; rel="last"`)
+					w.Write(getJSONList(getJSON("a", "1")))
+				},
+				"filter=a&limit=1&page=2": func(w http.ResponseWriter) {
+					w.Header().Set("Link", `</test?filter=a&limit=1&page=3>; rel="next"`)
+					w.Write(getJSONList(getJSON("b", "2")))
+				},
+				"filter=a&limit=1&page=3": func(w http.ResponseWriter) {
+					w.Write(getJSONList(getJSON("c", "3")))
+				},
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}, {Name: "b", ID: "2"}, {Name: "c", ID: "3"}}},
+		},
+		{
+			name:         "cursor_field",
+			continuation: CursorContinuation("paging.cursor", "after"),
+			pages: map[string]func(w http.ResponseWriter){
+				"filter=a&limit=1": func(w http.ResponseWriter) {
+					w.Write([]byte(`{"paging":{"cursor":42},"items":[{"name":"a","id":"1"}]}`))
+				},
+				"after=42&filter=a&limit=1": func(w http.ResponseWriter) {
+					w.Write([]byte(`{"paging":{"cursor":""},"items":[]}`))
+				},
+			},
+			want: &testObjList{Items: []testObj{{Name: "a", ID: "1"}}},
+		},
+	}
+
+	for _, c := range cases {
+		requested := 0
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/test" {
+				t.Errorf("ListAll(%q) got path %s. wanted /test", c.name, r.URL.Path)
+			}
+			page, ok := c.pages[r.URL.RawQuery]
+			if !ok {
+				t.Errorf("ListAll(%q) got unexpected query %s", c.name, r.URL.RawQuery)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			requested++
+			w.Header().Set("Content-Type", "application/json")
+			page(w)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		// the page size is either the one of the pager, or the limit of the option
+		pagers := []struct {
+			pager  *Pager
+			option types.Option
+		}{
+			{
+				pager:  &Pager{Client: cli, Continuation: c.continuation, PageSize: 1},
+				option: &types.Options{Params: types.QueryParameters{"filter": "a"}},
+			},
+			{
+				pager:  &Pager{Client: cli, Continuation: c.continuation},
+				option: &types.ListOptions{Limit: 1, Params: url.Values{"filter": []string{"a"}}},
+			},
+		}
+		for _, p := range pagers {
+			requested = 0
+			got := &testObjList{}
+			if err := p.pager.ListAll(context.TODO(), got, p.option); nil != err {
+				t.Errorf("unexpected error when listing %q: %v", c.name, err)
+				continue
+			}
+			if requested != len(c.pages) {
+				t.Errorf("ListAll(%q) requested %d pages. wanted %d", c.name, requested, len(c.pages))
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("ListAll(%q) want: %v\ngot: %v", c.name, c.want, got)
+			}
+		}
+	}
+}
+
+func TestPagerIterate(t *testing.T) {
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch token := r.URL.Query().Get("continue"); token {
+		case "":
+			w.Write([]byte(`{"metadata":{"continue":"t1"},"items":[{"name":"a","id":"1"}]}`))
+		case "t1":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	pager := &Pager{Client: cli}
+	it := pager.Iterate(func() ObjectList { return &testObjList{} }, nil)
+	pages := 0
+	for it.Next(context.TODO()) {
+		pages++
+		if want := (&testObjList{Items: []testObj{{Name: "a", ID: "1"}}}); !reflect.DeepEqual(it.Item(), want) {
+			t.Errorf("Iterate want: %v\ngot: %v", want, it.Item())
+		}
+	}
+	if pages != 1 {
+		t.Errorf("Iterate got %d pages. wanted 1", pages)
+	}
+	if nil == it.Err() {
+		t.Errorf("Iterate wanted an error on the second page")
+	}
+	if it.Next(context.TODO()) {
+		t.Errorf("Iterate wanted no more pages after an error")
+	}
+}
@@ -2,10 +2,19 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	types2 "github.com/alauda/kube-rest/pkg/types"
 
 	jsonpatch "github.com/evanphx/json-patch"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
 )
 
 type patch struct {
@@ -29,7 +38,7 @@ func ConstantPatch(patchType types.PatchType, data []byte) Patch {
 }
 
 type mergeFromPatch struct {
-	from runtime.Object
+	from interface{}
 }
 
 // Type implements patch.
@@ -39,12 +48,12 @@ func (s *mergeFromPatch) Type() types.PatchType {
 
 // Data implements Patch.
 func (s *mergeFromPatch) Data(obj Object) ([]byte, error) {
-	originalJSON, err := json.Marshal(s.from)
+	originalJSON, err := objectJSON(s.from)
 	if err != nil {
 		return nil, err
 	}
 
-	modifiedJSON, err := json.Marshal(obj)
+	modifiedJSON, err := objectJSON(obj)
 	if err != nil {
 		return nil, err
 	}
@@ -52,24 +61,249 @@ func (s *mergeFromPatch) Data(obj Object) ([]byte, error) {
 	return jsonpatch.CreateMergePatch(originalJSON, modifiedJSON)
 }
 
-// MergeFrom creates a Patch that patches using the merge-patch strategy with the given object as base.
-func MergeFrom(obj runtime.Object) Patch {
+// MergeFrom creates a Patch that patches using the merge-patch strategy with the given object as base,
+// obj is encoded by itself if it's an Object, or as json otherwise.
+func MergeFrom(obj interface{}) Patch {
 	return &mergeFromPatch{obj}
 }
 
+// objectJSON returns the json data of obj, Objects are encoded by themselves and
+// converted to json if they are encoded as yaml.
+func objectJSON(obj interface{}) ([]byte, error) {
+	if e, ok := obj.(Encodable); ok {
+		return e.Encode(JSONCodec)
+	}
+	o, ok := obj.(Object)
+	if !ok {
+		return json.Marshal(obj)
+	}
+	data, err := o.Data()
+	if nil != err || json.Valid(data) {
+		return data, err
+	}
+	return yaml.YAMLToJSON(data)
+}
+
+type jsonPatch struct {
+	from        interface{}
+	versionPath string
+}
+
+// Type implements Patch.
+func (s *jsonPatch) Type() types.PatchType {
+	return types.JSONPatchType
+}
+
+// Data implements Patch.
+func (s *jsonPatch) Data(obj Object) ([]byte, error) {
+	originalJSON, err := objectJSON(s.from)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiedJSON, err := objectJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var original, modified interface{}
+	if err := json.Unmarshal(originalJSON, &original); nil != err {
+		return nil, err
+	}
+	if err := json.Unmarshal(modifiedJSON, &modified); nil != err {
+		return nil, err
+	}
+
+	ops := []jsonPatchOp{}
+	if len(s.versionPath) > 0 {
+		version, ok := jsonPointerValue(original, s.versionPath)
+		if !ok {
+			return nil, fmt.Errorf("version %q not found in the original object", s.versionPath)
+		}
+		ops = append(ops, jsonPatchOp{"op": "test", "path": s.versionPath, "value": version})
+	}
+	return json.Marshal(diffJSON(ops, "", original, modified))
+}
+
+// jsonPatchOp is a RFC 6902 operation.
+type jsonPatchOp map[string]interface{}
+
+// diffJSON appends the operations turning original into modified at path to ops.
+func diffJSON(ops []jsonPatchOp, path string, original, modified interface{}) []jsonPatchOp {
+	switch o := original.(type) {
+	case map[string]interface{}:
+		m, ok := modified.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(o)+len(m))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		for k := range m {
+			if _, ok := o[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "/" + jsonPointerEscaper.Replace(k)
+			ov, inOriginal := o[k]
+			mv, inModified := m[k]
+			switch {
+			case !inModified:
+				ops = append(ops, jsonPatchOp{"op": "remove", "path": p})
+			case !inOriginal:
+				ops = append(ops, jsonPatchOp{"op": "add", "path": p, "value": mv})
+			default:
+				ops = diffJSON(ops, p, ov, mv)
+			}
+		}
+		return ops
+	case []interface{}:
+		m, ok := modified.([]interface{})
+		if !ok {
+			break
+		}
+		i := 0
+		for ; i < len(o) && i < len(m); i++ {
+			ops = diffJSON(ops, path+"/"+strconv.Itoa(i), o[i], m[i])
+		}
+		for j := i; j < len(m); j++ {
+			ops = append(ops, jsonPatchOp{"op": "add", "path": path + "/" + strconv.Itoa(j), "value": m[j]})
+		}
+		// remove from the tail so that the indexes stay valid
+		for j := len(o) - 1; j >= i; j-- {
+			ops = append(ops, jsonPatchOp{"op": "remove", "path": path + "/" + strconv.Itoa(j)})
+		}
+		return ops
+	}
+	if !reflect.DeepEqual(original, modified) {
+		ops = append(ops, jsonPatchOp{"op": "replace", "path": path, "value": modified})
+	}
+	return ops
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+var jsonPointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// jsonPointerValue returns the value at the RFC 6901 pointer of doc.
+func jsonPointerValue(doc interface{}, pointer string) (interface{}, bool) {
+	if len(pointer) == 0 {
+		return doc, true
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = jsonPointerUnescaper.Replace(token)
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[token]
+			if !ok {
+				return nil, false
+			}
+			doc = v
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if nil != err || i < 0 || i >= len(d) {
+				return nil, false
+			}
+			doc = d[i]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// JSONPatchFrom creates a Patch that patches using the RFC 6902 json patch operations
+// diffed from the given object as base.
+func JSONPatchFrom(obj interface{}) Patch {
+	return &jsonPatch{from: obj}
+}
+
+// JSONPatchFromWithVersion is like JSONPatchFrom, but the patch starts with a "test" operation
+// on the version at versionPath, a RFC 6901 json pointer like "/metadata/resourceVersion",
+// so that the patch fails if the object has been changed since the base was read.
+func JSONPatchFromWithVersion(obj interface{}, versionPath string) Patch {
+	return &jsonPatch{from: obj, versionPath: versionPath}
+}
+
+type strategicMergePatch struct {
+	from interface{}
+}
+
+// Type implements Patch.
+func (s *strategicMergePatch) Type() types.PatchType {
+	return types.StrategicMergePatchType
+}
+
+// Data implements Patch.
+func (s *strategicMergePatch) Data(obj Object) ([]byte, error) {
+	originalJSON, err := objectJSON(s.from)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiedJSON, err := objectJSON(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataStruct interface{} = obj
+	if u, ok := obj.(interface{ underlying() interface{} }); ok {
+		dataStruct = u.underlying()
+	}
+	return strategicpatch.CreateTwoWayMergePatch(originalJSON, modifiedJSON, dataStruct)
+}
+
+// StrategicMergeFrom creates a Patch that patches using the strategic-merge-patch strategy
+// with the given object as base. Lists are merged according to the patchStrategy and
+// patchMergeKey struct tags of the object, e.g.:
+//
+//	Containers []Container `json:"containers" patchStrategy:"merge" patchMergeKey:"name"`
+func StrategicMergeFrom(obj interface{}) Patch {
+	return &strategicMergePatch{from: obj}
+}
+
+var _ Patch = &applyPatch{}
+var _ types2.Option = &applyPatch{}
+
 // applyPatch uses server-side apply to patch the object.
-type applyPatch struct{}
+type applyPatch struct {
+	fieldManager string
+	force        bool
+}
 
 // Type implements Patch.
-func (p applyPatch) Type() types.PatchType {
+func (p *applyPatch) Type() types.PatchType {
 	return types.ApplyPatchType
 }
 
 // Data implements Patch.
-func (p applyPatch) Data(obj runtime.Object) ([]byte, error) {
-	// NB(directxman12): we might technically want to be using an actual encoder
-	// here (in case some more performant encoder is introduced) but this is
-	// correct and sufficient for our uses (it's what the JSON serializer in
-	// client-go does, more-or-less).
-	return json.Marshal(obj)
+func (p *applyPatch) Data(obj Object) ([]byte, error) {
+	// the apply patch is the full intended state of the object, since json
+	// is valid yaml, the data of the object is sent as is.
+	return obj.Data()
+}
+
+// ApplyToRequest implements types.Option.
+func (p *applyPatch) ApplyToRequest(req *rest.Request) *rest.Request {
+	if len(p.fieldManager) > 0 {
+		req = req.Param("fieldManager", p.fieldManager)
+	}
+	if p.force {
+		req = req.Param("force", "true")
+	}
+	return req
+}
+
+// IsIdempotent implements types.Idempotent, applying the same object twice has no further effect.
+func (p *applyPatch) IsIdempotent() bool {
+	return true
+}
+
+// Apply creates a Patch that patches using server-side apply with the object as its intended state.
+// fieldManager is the name of the actor managing the applied fields, force makes
+// the apply take the ownership of the fields conflicting with other managers.
+func Apply(fieldManager string, force bool) Patch {
+	return &applyPatch{fieldManager: fieldManager, force: force}
 }
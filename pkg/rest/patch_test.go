@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"encoding/json"
+	"testing"
+
+	types2 "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+type patchContainer struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+}
+
+type patchObj struct {
+	testObj
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Containers []patchContainer  `json:"containers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+func (p *patchObj) Data() ([]byte, error) {
+	return json.Marshal(p)
+}
+
+func TestJSONPatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		patch    func(from *patchObj) Patch
+		from     *patchObj
+		modified *patchObj
+		want     string
+	}{
+		{
+			name:     "replace",
+			patch:    func(from *patchObj) Patch { return JSONPatchFrom(from) },
+			from:     &patchObj{testObj: testObj{Name: "a", ID: "b"}},
+			modified: &patchObj{testObj: testObj{Name: "a", ID: "b1"}},
+			want:     `[{"op":"replace","path":"/id","value":"b1"}]`,
+		},
+		{
+			name:     "add_remove",
+			patch:    func(from *patchObj) Patch { return JSONPatchFrom(from) },
+			from:     &patchObj{Metadata: map[string]string{"a/b": "1", "c": "2"}, Tags: []string{"x", "y", "z"}},
+			modified: &patchObj{Metadata: map[string]string{"c": "2", "d~": "3"}, Tags: []string{"x"}},
+			want: `[{"op":"remove","path":"/metadata/a~1b"},{"op":"add","path":"/metadata/d~0","value":"3"},` +
+				`{"op":"remove","path":"/tags/2"},{"op":"remove","path":"/tags/1"}]`,
+		},
+		{
+			name:     "add_field_and_item",
+			patch:    func(from *patchObj) Patch { return JSONPatchFrom(from) },
+			from:     &patchObj{Tags: []string{"x"}},
+			modified: &patchObj{Metadata: map[string]string{"a": "1"}, Tags: []string{"x", "y"}},
+			want:     `[{"op":"add","path":"/metadata","value":{"a":"1"}},{"op":"add","path":"/tags/1","value":"y"}]`,
+		},
+		{
+			name: "test_version",
+			patch: func(from *patchObj) Patch {
+				return JSONPatchFromWithVersion(from, "/metadata/resourceVersion")
+			},
+			from:     &patchObj{testObj: testObj{ID: "b"}, Metadata: map[string]string{"resourceVersion": "7"}},
+			modified: &patchObj{testObj: testObj{ID: "b1"}, Metadata: map[string]string{"resourceVersion": "7"}},
+			want:     `[{"op":"test","path":"/metadata/resourceVersion","value":"7"},{"op":"replace","path":"/id","value":"b1"}]`,
+		},
+		{
+			name:     "strategic_merge",
+			patch:    func(from *patchObj) Patch { return StrategicMergeFrom(from) },
+			from:     &patchObj{Containers: []patchContainer{{"a", "a:1"}, {"b", "b:1"}}},
+			modified: &patchObj{Containers: []patchContainer{{"a", "a:2"}, {"b", "b:1"}}},
+			want:     `{"$setElementOrder/containers":[{"name":"a"},{"name":"b"}],"containers":[{"image":"a:2","name":"a"}]}`,
+		},
+	}
+
+	for _, c := range cases {
+		patch := c.patch(c.from)
+		got, err := patch.Data(c.modified)
+		if nil != err {
+			t.Errorf("unexpected error when patching %q: %v", c.name, err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("Patch(%q) want: %s\ngot: %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestJSONPatchMissingVersion(t *testing.T) {
+	patch := JSONPatchFromWithVersion(&patchObj{}, "/metadata/resourceVersion")
+	if patch.Type() != types2.JSONPatchType {
+		t.Errorf("Patch got type %s. wanted %s", patch.Type(), types2.JSONPatchType)
+	}
+	if _, err := patch.Data(&patchObj{}); nil == err {
+		t.Errorf("Patch wanted an error for the missing version")
+	}
+}
+
+// yamlPatchObj encodes its data as yaml.
+type yamlPatchObj struct {
+	patchObj
+}
+
+func (p *yamlPatchObj) Data() ([]byte, error) {
+	return yaml.Marshal(p)
+}
+
+func TestMergeFromYAML(t *testing.T) {
+	users := NewTypedClient[typedUser, typedUserList](nil, Resource[typedUser]{Path: "/test", Codec: YAMLCodec})
+	cases := []struct {
+		name     string
+		from     interface{}
+		modified Object
+		want     string
+	}{
+		{
+			name:     "yaml_data",
+			from:     &yamlPatchObj{patchObj{testObj: testObj{Name: "a", ID: "b"}, Tags: []string{"x"}}},
+			modified: &yamlPatchObj{patchObj{testObj: testObj{Name: "a", ID: "b1"}, Tags: []string{"x", "y"}}},
+			want:     `{"id":"b1","tags":["x","y"]}`,
+		},
+		{
+			name:     "yaml_codec",
+			from:     users.Object(&typedUser{Name: "a", ID: "b"}),
+			modified: users.Object(&typedUser{Name: "a", ID: "b1"}),
+			want:     `{"id":"b1"}`,
+		},
+	}
+
+	for _, c := range cases {
+		got, err := MergeFrom(c.from).Data(c.modified)
+		if nil != err {
+			t.Errorf("unexpected error when patching %q: %v", c.name, err)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("MergeFrom(%q) want: %s\ngot: %s", c.name, c.want, got)
+		}
+	}
+}
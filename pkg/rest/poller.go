@@ -0,0 +1,179 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+)
+
+// DefaultPollInterval is the interval of a Poller without one.
+const DefaultPollInterval = 30 * time.Second
+
+// KeyFunc returns the key identifying obj in a collection.
+type KeyFunc func(obj Object) string
+
+// VersionFunc returns the version of obj, obj is modified if its version changed.
+type VersionFunc func(obj Object) (string, error)
+
+// SelfLinkKey keys objects by their SelfLink.
+func SelfLinkKey(obj Object) string {
+	return obj.SelfLink()
+}
+
+// DataHash versions objects by the sha256 of their data.
+func DataHash(obj Object) (string, error) {
+	data, err := obj.Data()
+	if nil != err {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Poller detects the changes of a collection that can't be watched, by listing it
+// periodically and diffing every list against the previous one.
+type Poller struct {
+	Client Client
+	// NewList creates the list the collection is listed into.
+	NewList func() ObjectList
+	// Option is applied to every list request.
+	Option types.Option
+	// Key keys objects, SelfLinkKey if nil.
+	Key KeyFunc
+	// Version versions objects, DataHash if nil.
+	Version VersionFunc
+	// Interval is the initial wait between two lists, DefaultPollInterval if zero.
+	Interval time.Duration
+	// MinInterval and MaxInterval bound an adaptive interval, which is halved after
+	// a list with changes and doubled after one without. The interval is fixed if they are zero.
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// Jitter adds a random wait of up to Jitter times the interval.
+	Jitter float64
+}
+
+type polled struct {
+	obj     Object
+	version string
+}
+
+// Poll lists the collection until ctx is done. The objects of the first list are sent
+// as added, then the changes of every list as added, modified or deleted events, and
+// failed lists as error events. The returned channel is closed when ctx is done.
+func (p *Poller) Poll(ctx context.Context) <-chan Event {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		interval := p.Interval
+		if 0 == interval {
+			interval = DefaultPollInterval
+		}
+		var previous map[string]polled
+		for {
+			current, changes, err := p.poll(ctx, previous)
+			if nil != err {
+				changes = []Event{{Type: http.Error, Err: err}}
+			} else {
+				previous = current
+			}
+			for _, event := range changes {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if nil == err {
+				interval = p.adapt(interval, len(changes) > 0)
+			}
+			timer := time.NewTimer(p.jitter(interval))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// poll lists the collection and diffs it against previous.
+func (p *Poller) poll(ctx context.Context, previous map[string]polled) (map[string]polled, []Event, error) {
+	list := p.NewList()
+	if err := p.Client.List(ctx, list, p.Option); nil != err {
+		return nil, nil, err
+	}
+	objects, err := ListObjects(list)
+	if nil != err {
+		return nil, nil, err
+	}
+	key, version := p.Key, p.Version
+	if nil == key {
+		key = SelfLinkKey
+	}
+	if nil == version {
+		version = DataHash
+	}
+
+	current := make(map[string]polled, len(objects))
+	changes := []Event{}
+	for _, obj := range objects {
+		k := key(obj)
+		v, err := version(obj)
+		if nil != err {
+			return nil, nil, err
+		}
+		current[k] = polled{obj: obj, version: v}
+		if old, ok := previous[k]; !ok {
+			changes = append(changes, Event{Type: http.Added, Object: obj})
+		} else if old.version != v {
+			changes = append(changes, Event{Type: http.Modified, Object: obj})
+		}
+	}
+	deleted := []string{}
+	for k := range previous {
+		if _, ok := current[k]; !ok {
+			deleted = append(deleted, k)
+		}
+	}
+	sort.Strings(deleted)
+	for _, k := range deleted {
+		changes = append(changes, Event{Type: http.Deleted, Object: previous[k].obj})
+	}
+	return current, changes, nil
+}
+
+func (p *Poller) adapt(interval time.Duration, changed bool) time.Duration {
+	if 0 == p.MinInterval && 0 == p.MaxInterval {
+		return interval
+	}
+	if changed {
+		interval /= 2
+	} else {
+		interval *= 2
+	}
+	if p.MinInterval > 0 && interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+func (p *Poller) jitter(interval time.Duration) time.Duration {
+	if p.Jitter > 0 {
+		interval += time.Duration(rand.Float64() * p.Jitter * float64(interval))
+	}
+	return interval
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	http2 "github.com/alauda/kube-rest/pkg/http"
+)
+
+func TestPoller(t *testing.T) {
+	var lock sync.Mutex
+	items := [][]byte{getJSON("a", "1"), getJSON("b", "1")}
+	status := http.StatusOK
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(getJSONList(items...))
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	poller := &Poller{
+		Client:   cli,
+		NewList:  func() ObjectList { return &testObjList{} },
+		Interval: 5 * time.Millisecond,
+		Jitter:   0.5,
+	}
+	events := poller.Poll(ctx)
+
+	next := func(n int) []string {
+		got := []string{}
+		for i := 0; i < n; i++ {
+			e := <-events
+			if e.Type == http2.Error {
+				got = append(got, string(e.Type))
+				continue
+			}
+			obj := e.Object.(*testObj)
+			got = append(got, string(e.Type)+" "+obj.Name+" "+obj.ID)
+		}
+		return got
+	}
+	change := func(code int, objs ...[]byte) {
+		lock.Lock()
+		defer lock.Unlock()
+		status, items = code, objs
+	}
+
+	if got, want := next(2), []string{"ADDED a 1", "ADDED b 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll want: %v\ngot: %v", want, got)
+	}
+	change(http.StatusOK, getJSON("a", "2"), getJSON("c", "1"))
+	if got, want := next(3), []string{"MODIFIED a 2", "ADDED c 1", "DELETED b 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll want: %v\ngot: %v", want, got)
+	}
+	change(http.StatusInternalServerError)
+	if got, want := next(1), []string{"ERROR"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll want: %v\ngot: %v", want, got)
+	}
+	// a failed list doesn't reset the snapshot
+	change(http.StatusOK, getJSON("c", "2"))
+	got := next(1)
+	for got[0] == "ERROR" {
+		got = next(1)
+	}
+	if got, want := append(got, next(1)...), []string{"MODIFIED c 2", "DELETED a 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Poll want: %v\ngot: %v", want, got)
+	}
+	cancel()
+	for range events {
+	}
+}
+
+func TestPollerInterval(t *testing.T) {
+	p := &Poller{MinInterval: time.Second, MaxInterval: 4 * time.Second}
+	cases := []struct {
+		interval time.Duration
+		changed  bool
+		want     time.Duration
+	}{
+		{interval: 2 * time.Second, changed: true, want: time.Second},
+		{interval: time.Second, changed: true, want: time.Second},
+		{interval: 2 * time.Second, changed: false, want: 4 * time.Second},
+		{interval: 4 * time.Second, changed: false, want: 4 * time.Second},
+	}
+	for _, c := range cases {
+		if got := p.adapt(c.interval, c.changed); got != c.want {
+			t.Errorf("adapt(%s, %v) got %s. wanted %s", c.interval, c.changed, got, c.want)
+		}
+	}
+	if got := (&Poller{}).adapt(time.Second, true); got != time.Second {
+		t.Errorf("adapt got %s. wanted a fixed interval", got)
+	}
+}
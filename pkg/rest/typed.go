@@ -0,0 +1,304 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Resource declares where a rest resource of type T lives and how it is encoded.
+type Resource[T any] struct {
+	// Path is the collection path of the resource, e.g. "/api/v1/users".
+	Path string
+	// Name returns the name of obj, which is joined to Path to build its self link.
+	// If Name is nil, obj is expected to implement interface{ GetName() string }.
+	Name func(obj *T) string
+	// Codec encodes and decodes the resource with clients without codecs, JSONCodec is used if nil.
+	// Clients with codecs use the codec negotiated with the server instead.
+	Codec Codec
+}
+
+func (r *Resource[T]) codec() Codec {
+	if nil == r.Codec {
+		return JSONCodec
+	}
+	return r.Codec
+}
+
+func (r *Resource[T]) name(obj *T) string {
+	if nil != r.Name {
+		return r.Name(obj)
+	}
+	if named, ok := interface{}(obj).(interface{ GetName() string }); ok {
+		return named.GetName()
+	}
+	return ""
+}
+
+// ErrUnnamed is returned by the calls of a TypedClient on a single object without a name,
+// whose self link would be the collection.
+var ErrUnnamed = errors.New("object has no name")
+
+var _ Object = &typedObject[struct{}]{}
+var _ Prototype = &typedObject[struct{}]{}
+var _ Versioned = &typedObject[struct{}]{}
+var _ ObjectList = &typedObjectList[struct{}, struct{}]{}
+var _ ListPrototype = &typedObjectList[struct{}, struct{}]{}
+var _ ItemLister = &typedObjectList[struct{}, struct{}]{}
+var _ Encodable = &typedObject[struct{}]{}
+var _ Encodable = &typedObjectList[struct{}, struct{}]{}
+var _ ResourceVersioned = &typedObjectList[struct{}, struct{}]{}
+
+// typedObject adapts *T to Object according to its resource.
+type typedObject[T any] struct {
+	obj      *T
+	resource *Resource[T]
+}
+
+func (o *typedObject[T]) TypeLink(segments ...string) string {
+	return path.Join(append([]string{o.resource.Path}, segments...)...)
+}
+
+func (o *typedObject[T]) SelfLink(segments ...string) string {
+	return path.Join(append([]string{o.resource.Path, o.resource.name(o.obj)}, segments...)...)
+}
+
+func (o *typedObject[T]) Data() ([]byte, error) {
+	return o.Encode(o.resource.codec())
+}
+
+func (o *typedObject[T]) Parse(bt []byte) error {
+	return o.Decode(o.resource.codec(), bt)
+}
+
+// Encode implements Encodable
+func (o *typedObject[T]) Encode(codec Codec) ([]byte, error) {
+	return codec.Encode(o.obj)
+}
+
+// Decode implements Encodable
+func (o *typedObject[T]) Decode(codec Codec, bt []byte) error {
+	clone := new(T)
+	if err := codec.Decode(bt, clone); nil != err {
+		return err
+	}
+	*o.obj = *clone
+	return nil
+}
+
+func (o *typedObject[T]) New() Object {
+	return &typedObject[T]{obj: new(T), resource: o.resource}
+}
+
+func (o *typedObject[T]) underlying() interface{} {
+	return o.obj
+}
+
+// GetETag implements Versioned if T does.
+func (o *typedObject[T]) GetETag() string {
+	if v, ok := interface{}(o.obj).(Versioned); ok {
+		return v.GetETag()
+	}
+	return ""
+}
+
+// SetETag implements Versioned if T does.
+func (o *typedObject[T]) SetETag(etag string) {
+	if v, ok := interface{}(o.obj).(Versioned); ok {
+		v.SetETag(etag)
+	}
+}
+
+// typedObjectList adapts *L to ObjectList according to the resource of T.
+type typedObjectList[T any, L any] struct {
+	list     *L
+	resource *Resource[T]
+}
+
+func (o *typedObjectList[T, L]) TypeLink() string {
+	return o.resource.Path
+}
+
+func (o *typedObjectList[T, L]) Parse(bt []byte) error {
+	return o.Decode(o.resource.codec(), bt)
+}
+
+// Encode implements Encodable
+func (o *typedObjectList[T, L]) Encode(codec Codec) ([]byte, error) {
+	return codec.Encode(o.list)
+}
+
+// Decode implements Encodable
+func (o *typedObjectList[T, L]) Decode(codec Codec, bt []byte) error {
+	clone := new(L)
+	if err := codec.Decode(bt, clone); nil != err {
+		return err
+	}
+	*o.list = *clone
+	return nil
+}
+
+func (o *typedObjectList[T, L]) New() ObjectList {
+	return &typedObjectList[T, L]{list: new(L), resource: o.resource}
+}
+
+func (o *typedObjectList[T, L]) underlying() interface{} {
+	return o.list
+}
+
+// Objects implements ItemLister, the items of L are expected to be T.
+func (o *typedObjectList[T, L]) Objects() []Object {
+	items, err := listItems(o.list)
+	if nil != err {
+		return nil
+	}
+	objects := make([]Object, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		if obj, ok := items.Index(i).Addr().Interface().(*T); ok {
+			objects = append(objects, &typedObject[T]{obj: obj, resource: o.resource})
+		}
+	}
+	return objects
+}
+
+// GetResourceVersion implements ResourceVersioned if L does.
+func (o *typedObjectList[T, L]) GetResourceVersion() string {
+	if v, ok := interface{}(o.list).(ResourceVersioned); ok {
+		return v.GetResourceVersion()
+	}
+	return ""
+}
+
+// TypedEvent represents a single event to a watched object of type T.
+type TypedEvent[T any] struct {
+	Type http.EventType
+	// Object is the changed object, it's nil if Type is http.Error.
+	Object *T
+	// Err is the error reported by the server if Type is http.Error.
+	Err error
+}
+
+// TypedClient knows how to perform CRUD operations on objects of type T,
+// listing them into L. It derives the Object and ObjectList implementations
+// from a Resource, so a plain struct is all a new rest resource needs.
+type TypedClient[T any, L any] struct {
+	client   Client
+	resource Resource[T]
+}
+
+// NewTypedClient creates a TypedClient for resource on top of client.
+func NewTypedClient[T any, L any](client Client, resource Resource[T]) *TypedClient[T, L] {
+	return &TypedClient[T, L]{client: client, resource: resource}
+}
+
+// Object adapts obj to Object, so that it could be used with Client and Patch directly.
+func (c *TypedClient[T, L]) Object(obj *T) Object {
+	return &typedObject[T]{obj: obj, resource: &c.resource}
+}
+
+// ObjectList adapts list to ObjectList, so that it could be used with Client directly.
+func (c *TypedClient[T, L]) ObjectList(list *L) ObjectList {
+	return &typedObjectList[T, L]{list: list, resource: &c.resource}
+}
+
+// FromObject returns the *T adapted by obj, e.g. an object received from a Watcher or
+// listed with ListObjects, or false if obj isn't an Object of T.
+func (c *TypedClient[T, L]) FromObject(obj Object) (*T, bool) {
+	if o, ok := obj.(*typedObject[T]); ok {
+		return o.obj, true
+	}
+	return nil, false
+}
+
+// named adapts obj to Object, it returns ErrUnnamed if obj has no name.
+func (c *TypedClient[T, L]) named(obj *T) (Object, error) {
+	if len(c.resource.name(obj)) == 0 {
+		return nil, ErrUnnamed
+	}
+	return c.Object(obj), nil
+}
+
+// Get retrieves obj from the server, obj is updated with the response.
+func (c *TypedClient[T, L]) Get(ctx context.Context, obj *T, option types.Option) error {
+	o, err := c.named(obj)
+	if nil != err {
+		return err
+	}
+	return c.client.Get(ctx, o, option)
+}
+
+// List retrieves the collection of the resource into list.
+func (c *TypedClient[T, L]) List(ctx context.Context, list *L, option types.Option) error {
+	return c.client.List(ctx, c.ObjectList(list), option)
+}
+
+// Create saves obj to the server, obj is updated with the response.
+func (c *TypedClient[T, L]) Create(ctx context.Context, obj *T, option types.Option) error {
+	return c.client.Create(ctx, c.Object(obj), option)
+}
+
+// Update updates obj on the server, obj is updated with the response.
+func (c *TypedClient[T, L]) Update(ctx context.Context, obj *T, option types.Option) error {
+	o, err := c.named(obj)
+	if nil != err {
+		return err
+	}
+	return c.client.Update(ctx, o, option)
+}
+
+// Delete deletes obj from the server, obj is updated with the final object if the
+// server returns it, otherwise the Status returned by the server is returned.
+func (c *TypedClient[T, L]) Delete(ctx context.Context, obj *T, option types.Option) (*metav1.Status, error) {
+	o, err := c.named(obj)
+	if nil != err {
+		return nil, err
+	}
+	return c.client.Delete(ctx, o, option)
+}
+
+// DeleteCollection deletes the objects of the resource selected by option, list is updated
+// with the deleted objects if the server returns them, otherwise the Status is returned.
+func (c *TypedClient[T, L]) DeleteCollection(ctx context.Context, list *L, option types.Option) (*metav1.Status, error) {
+	return c.client.DeleteCollection(ctx, c.ObjectList(list), option)
+}
+
+// Patch patches obj on the server, obj is updated with the response.
+func (c *TypedClient[T, L]) Patch(ctx context.Context, obj *T, patch Patch, option types.Option) error {
+	o, err := c.named(obj)
+	if nil != err {
+		return err
+	}
+	return c.client.Patch(ctx, o, patch, option)
+}
+
+// Watch watches the collection of the resource, see Watcher.
+func (c *TypedClient[T, L]) Watch(ctx context.Context, option types.Option) (<-chan TypedEvent[T], error) {
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	raw, err := c.client.Watch(ctx, c.Object(new(T)), option)
+	if nil != err {
+		return nil, err
+	}
+	events := make(chan TypedEvent[T])
+	go func() {
+		defer close(events)
+		for e := range raw {
+			event := TypedEvent[T]{Type: e.Type, Err: e.Err}
+			if obj, ok := e.Object.(*typedObject[T]); ok {
+				event.Object = obj.obj
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,149 @@
+package rest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type typedUser struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+func (u *typedUser) GetName() string {
+	return u.Name
+}
+
+type typedUserList struct {
+	Items []typedUser `json:"items"`
+}
+
+var typedUsers = Resource[typedUser]{Path: "/test"}
+
+func TestTypedClient(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		resp   []byte
+		do     func(c *TypedClient[typedUser, typedUserList]) (interface{}, error)
+		want   interface{}
+	}{
+		{
+			name:   "typed_get",
+			method: "GET",
+			path:   "/test/a",
+			resp:   getJSON("a", "b"),
+			do: func(c *TypedClient[typedUser, typedUserList]) (interface{}, error) {
+				got := &typedUser{Name: "a"}
+				return got, c.Get(context.TODO(), got, nil)
+			},
+			want: &typedUser{Name: "a", ID: "b"},
+		},
+		{
+			name:   "typed_list",
+			method: "GET",
+			path:   "/test",
+			resp:   getJSONList(getJSON("a", "b"), getJSON("c", "d")),
+			do: func(c *TypedClient[typedUser, typedUserList]) (interface{}, error) {
+				got := &typedUserList{}
+				return got, c.List(context.TODO(), got, nil)
+			},
+			want: &typedUserList{Items: []typedUser{{Name: "a", ID: "b"}, {Name: "c", ID: "d"}}},
+		},
+		{
+			name:   "typed_create",
+			method: "POST",
+			path:   "/test",
+			resp:   getJSON("a", "b"),
+			do: func(c *TypedClient[typedUser, typedUserList]) (interface{}, error) {
+				got := &typedUser{Name: "a", ID: "b"}
+				return got, c.Create(context.TODO(), got, defaultOptions)
+			},
+			want: &typedUser{Name: "a", ID: "b"},
+		},
+		{
+			name:   "typed_update",
+			method: "PUT",
+			path:   "/test/a",
+			resp:   getJSON("a", "b1"),
+			do: func(c *TypedClient[typedUser, typedUserList]) (interface{}, error) {
+				got := &typedUser{Name: "a", ID: "b1"}
+				return got, c.Update(context.TODO(), got, defaultOptions)
+			},
+			want: &typedUser{Name: "a", ID: "b1"},
+		},
+	}
+
+	for _, c := range cases {
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != c.method {
+				t.Errorf("%q got HTTP method %s. wanted %s", c.name, r.Method, c.method)
+			}
+
+			if r.URL.Path != c.path {
+				t.Errorf("%q got path %s. wanted %s", c.name, r.URL.Path, c.path)
+			}
+
+			if r.Method == "POST" || r.Method == "PUT" {
+				data, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("%q unexpected error reading body: %v", c.name, err)
+				}
+				if !reflect.DeepEqual(c.resp, data) {
+					t.Errorf("%q got data %s. wanted %s", c.name, data, c.resp)
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(c.resp)
+		})
+
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+
+		defer srv.Close()
+
+		got, err := c.do(NewTypedClient[typedUser, typedUserList](cli, typedUsers))
+
+		if nil != err {
+			t.Errorf("unexpected error in %q: %v", c.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q want: %v\ngot: %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestTypedClientUnnamed(t *testing.T) {
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unnamed object got %s %s. wanted no request", r.Method, r.URL.Path)
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	users := NewTypedClient[typedUser, typedUserList](cli, typedUsers)
+	unnamed := &typedUser{ID: "b"}
+	calls := map[string]func() error{
+		"get":    func() error { return users.Get(context.TODO(), unnamed, nil) },
+		"update": func() error { return users.Update(context.TODO(), unnamed, nil) },
+		"patch":  func() error { return users.Patch(context.TODO(), unnamed, MergeFrom(&typedUser{}), nil) },
+		"delete": func() error {
+			_, err := users.Delete(context.TODO(), unnamed, nil)
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); err != ErrUnnamed {
+			t.Errorf("%q of an unnamed object got error %v. wanted %v", name, err, ErrUnnamed)
+		}
+	}
+}
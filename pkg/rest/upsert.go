@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// OperationResult is the action taken by CreateOrUpdate and CreateOrPatch.
+type OperationResult string
+
+const (
+	// OperationResultNone means the object is unchanged, nothing is written.
+	OperationResultNone OperationResult = "unchanged"
+	// OperationResultCreated means the object is created.
+	OperationResultCreated OperationResult = "created"
+	// OperationResultUpdated means the object is updated.
+	OperationResultUpdated OperationResult = "updated"
+)
+
+// CreateOrUpdate gets obj and applies mutate to it, then creates it if it's not found,
+// or updates it if mutate changed it. The object is unchanged if its Data is the same
+// before and after mutate.
+func CreateOrUpdate(ctx context.Context, c Client, obj Object, mutate func() error) (OperationResult, error) {
+	return createOr(ctx, c, obj, mutate, func(original Object) error {
+		return c.Update(ctx, obj, nil)
+	})
+}
+
+// CreateOrPatch is like CreateOrUpdate, but sends the changes made by mutate as a
+// merge patch with MergeFrom instead of updating the whole object.
+func CreateOrPatch(ctx context.Context, c Client, obj Object, mutate func() error) (OperationResult, error) {
+	return createOr(ctx, c, obj, mutate, func(original Object) error {
+		return c.Patch(ctx, obj, MergeFrom(original), nil)
+	})
+}
+
+// createOr creates obj if it's not found, or calls write with a copy of obj taken before mutate.
+func createOr(ctx context.Context, c Client, obj Object, mutate func() error, write func(original Object) error) (OperationResult, error) {
+	if err := c.Get(ctx, obj, nil); nil != err {
+		if !apiError.IsNotFound(err) {
+			return OperationResultNone, err
+		}
+		if err := mutate(); nil != err {
+			return OperationResultNone, err
+		}
+		if err := c.Create(ctx, obj, nil); nil != err {
+			return OperationResultNone, err
+		}
+		return OperationResultCreated, nil
+	}
+
+	before, err := obj.Data()
+	if nil != err {
+		return OperationResultNone, err
+	}
+	original := NewObject(obj)
+	if err := original.Parse(before); nil != err {
+		return OperationResultNone, err
+	}
+	if err := mutate(); nil != err {
+		return OperationResultNone, err
+	}
+	after, err := obj.Data()
+	if nil != err {
+		return OperationResultNone, err
+	}
+	if bytes.Equal(before, after) {
+		return OperationResultNone, nil
+	}
+	if err := write(original); nil != err {
+		return OperationResultNone, err
+	}
+	return OperationResultUpdated, nil
+}
@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestCreateOrUpdate(t *testing.T) {
+	cases := []struct {
+		name    string
+		stored  []byte
+		id      string
+		upsert  func(context.Context, Client, Object, func() error) (OperationResult, error)
+		result  OperationResult
+		request string
+		body    string
+	}{
+		{
+			name:    "update_created",
+			id:      "1",
+			upsert:  CreateOrUpdate,
+			result:  OperationResultCreated,
+			request: "POST",
+			body:    `{"name":"a","id":"1"}`,
+		},
+		{
+			name:   "update_unchanged",
+			stored: getJSON("a", "1"),
+			id:     "1",
+			upsert: CreateOrUpdate,
+			result: OperationResultNone,
+		},
+		{
+			name:    "update_updated",
+			stored:  getJSON("a", "1"),
+			id:      "2",
+			upsert:  CreateOrUpdate,
+			result:  OperationResultUpdated,
+			request: "PUT",
+			body:    `{"name":"a","id":"2"}`,
+		},
+		{
+			name:    "patch_created",
+			id:      "1",
+			upsert:  CreateOrPatch,
+			result:  OperationResultCreated,
+			request: "POST",
+			body:    `{"name":"a","id":"1"}`,
+		},
+		{
+			name:   "patch_unchanged",
+			stored: getJSON("a", "1"),
+			id:     "1",
+			upsert: CreateOrPatch,
+			result: OperationResultNone,
+		},
+		{
+			name:    "patch_updated",
+			stored:  getJSON("a", "1"),
+			id:      "2",
+			upsert:  CreateOrPatch,
+			result:  OperationResultUpdated,
+			request: "PATCH",
+			body:    `{"id":"2"}`,
+		},
+	}
+
+	for _, c := range cases {
+		stored := c.stored
+		request, body := "", ""
+		cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.Method == "GET" {
+				if nil == stored {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Write(stored)
+				return
+			}
+			bt, _ := ioutil.ReadAll(r.Body)
+			request, body = r.Method, string(bt)
+			w.Write(getJSON("a", c.id))
+		})
+		if nil != err {
+			t.Errorf("unexpected error when creating client: %v", err)
+			continue
+		}
+		defer srv.Close()
+
+		obj := &testObj{Name: "a"}
+		result, err := c.upsert(context.TODO(), cli, obj, func() error {
+			obj.ID = c.id
+			return nil
+		})
+		if nil != err {
+			t.Errorf("CreateOrUpdate(%q) got error %v", c.name, err)
+			continue
+		}
+		if result != c.result {
+			t.Errorf("CreateOrUpdate(%q) got result %s. wanted %s", c.name, result, c.result)
+		}
+		if request != c.request || body != c.body {
+			t.Errorf("CreateOrUpdate(%q) got request %s %s. wanted %s %s", c.name, request, body, c.request, c.body)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	nethttp "net/http"
+	"net/url"
+
+	"github.com/alauda/kube-rest/pkg/http"
+	"github.com/alauda/kube-rest/pkg/types"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Versioned is an Object that keeps the ETag the server returned for it.
+//
+// Client records the ETag of the responses of Get, Create, Update and Patch into it,
+// and sends it as If-Match with Update, Patch and Delete, so that a change made
+// by others since the object was read fails the request with a VersionConflictError.
+type Versioned interface {
+	GetETag() string
+	SetETag(etag string)
+}
+
+// ResourceVersioned is an ObjectList that tells the resourceVersion it was listed at,
+// e.g. a list embedding metav1.ListMeta. The cache informer watches from it.
+type ResourceVersioned interface {
+	GetResourceVersion() string
+}
+
+// VersionConflictError is the error of a conditional request failed with
+// 412 Precondition Failed, that is the object has been changed since its ETag was read.
+type VersionConflictError struct {
+	// ETag is the version the request was conditional on.
+	ETag string
+	// Err is the error returned by the server.
+	Err error
+}
+
+var _ apiError.APIStatus = &VersionConflictError{}
+
+// Error implements error.
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("the object has been modified since version %s: %v", e.ETag, e.Err)
+}
+
+// Unwrap returns the error returned by the server.
+func (e *VersionConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Status implements apiError.APIStatus, it's a Conflict.
+func (e *VersionConflictError) Status() metav1.Status {
+	status := apiError.NewConflict(schema.GroupResource{}, "", e).ErrStatus
+	status.Message = e.Error()
+	return status
+}
+
+// IsVersionConflict returns true if err is a VersionConflictError.
+func IsVersionConflict(err error) bool {
+	var e *VersionConflictError
+	return errors.As(err, &e)
+}
+
+// GetIfModified gets obj if it has been modified since its ETag, by sending the
+// ETag as If-None-Match. obj is kept as is and false is returned if the server
+// responds 304 Not Modified.
+func GetIfModified(ctx context.Context, c Client, obj Object, option types.Option) (bool, error) {
+	if versioned, ok := obj.(Versioned); ok && len(versioned.GetETag()) > 0 {
+		option = &chainOption{option, header("If-None-Match", versioned.GetETag())}
+	}
+	err := c.Get(ctx, obj, option)
+	if http.StatusCode(err) == nethttp.StatusNotModified {
+		return false, nil
+	}
+	return nil == err, err
+}
+
+// ifMatch makes the request conditional on etag.
+func ifMatch(etag string) types.Option {
+	return header("If-Match", etag)
+}
+
+func header(key, value string) types.Option {
+	return &types.Options{Header: url.Values{key: []string{value}}}
+}
@@ -0,0 +1,132 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	apiError "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type versionedObj struct {
+	testObj
+	etag string
+}
+
+func (v *versionedObj) GetETag() string {
+	return v.etag
+}
+
+func (v *versionedObj) SetETag(etag string) {
+	v.etag = etag
+}
+
+func TestVersioned(t *testing.T) {
+	version := `"1"`
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case "GET":
+			if r.Header.Get("If-None-Match") == version {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "PUT", "DELETE":
+			if r.Header.Get("If-Match") != version {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			version = `"2"`
+		}
+		w.Header().Set("ETag", version)
+		w.Write(getJSON("a", "b"))
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	obj := &versionedObj{testObj: testObj{Name: "a"}}
+	if err := cli.Get(context.TODO(), obj, nil); nil != err {
+		t.Fatalf("unexpected error when getting: %v", err)
+	}
+	if obj.etag != `"1"` {
+		t.Errorf("Get got etag %s. wanted %s", obj.etag, `"1"`)
+	}
+
+	modified, err := GetIfModified(context.TODO(), cli, obj, nil)
+	if nil != err || modified {
+		t.Errorf("GetIfModified got %v, %v. wanted not modified", modified, err)
+	}
+
+	stale := &versionedObj{testObj: testObj{Name: "a"}, etag: `"0"`}
+	err = cli.Update(context.TODO(), stale, defaultOptions)
+	if !IsVersionConflict(err) || !apiError.IsConflict(err) {
+		t.Errorf("Update got error %v. wanted a version conflict", err)
+	}
+
+	if err := cli.Update(context.TODO(), obj, defaultOptions); nil != err {
+		t.Fatalf("unexpected error when updating: %v", err)
+	}
+	if obj.etag != `"2"` {
+		t.Errorf("Update got etag %s. wanted %s", obj.etag, `"2"`)
+	}
+
+	modified, err = GetIfModified(context.TODO(), cli, &versionedObj{testObj: testObj{Name: "a"}, etag: `"1"`}, nil)
+	if nil != err || !modified {
+		t.Errorf("GetIfModified got %v, %v. wanted modified", modified, err)
+	}
+
+	if _, err := cli.Delete(context.TODO(), stale, nil); !IsVersionConflict(err) {
+		t.Errorf("Delete got error %v. wanted a version conflict", err)
+	}
+}
+
+// versionedUser keeps its etag out of its json, like most Versioned types.
+type versionedUser struct {
+	typedUser
+	etag string
+}
+
+func (u *versionedUser) GetETag() string {
+	return u.etag
+}
+
+func (u *versionedUser) SetETag(etag string) {
+	u.etag = etag
+}
+
+func TestTypedVersioned(t *testing.T) {
+	cli, srv, err := getClientServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "PUT" && r.Header.Get("If-Match") != `"1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Method == "PUT" {
+			w.Header().Set("ETag", `"2"`)
+		} else {
+			w.Header().Set("ETag", `"1"`)
+		}
+		w.Write(getJSON("a", "b"))
+	})
+	if nil != err {
+		t.Fatalf("unexpected error when creating client: %v", err)
+	}
+	defer srv.Close()
+
+	users := NewTypedClient[versionedUser, struct{}](cli, Resource[versionedUser]{Path: "/test"})
+	user := &versionedUser{typedUser: typedUser{Name: "a"}}
+	if err := users.Get(context.TODO(), user, nil); nil != err {
+		t.Fatalf("unexpected error when getting: %v", err)
+	}
+	if user.etag != `"1"` {
+		t.Errorf("Get got etag %s. wanted %s", user.etag, `"1"`)
+	}
+	if err := users.Update(context.TODO(), user, nil); nil != err {
+		t.Fatalf("unexpected error when updating: %v", err)
+	}
+	if user.etag != `"2"` {
+		t.Errorf("Update got etag %s. wanted %s", user.etag, `"2"`)
+	}
+}
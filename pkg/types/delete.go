@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	"k8s.io/client-go/rest"
+)
+
+// DeletionPropagation decides whether and how the dependents of a deleted object are deleted.
+type DeletionPropagation string
+
+const (
+	// DeletePropagationOrphan orphans the dependents.
+	DeletePropagationOrphan DeletionPropagation = "Orphan"
+	// DeletePropagationBackground deletes the dependents in the background.
+	DeletePropagationBackground DeletionPropagation = "Background"
+	// DeletePropagationForeground deletes the dependents before the object.
+	DeletePropagationForeground DeletionPropagation = "Foreground"
+)
+
+// DryRunAll processes a request without persisting it.
+const DryRunAll = "All"
+
+// Preconditions must be fulfilled before an object is deleted.
+type Preconditions struct {
+	UID             *string `json:"uid,omitempty"`
+	ResourceVersion *string `json:"resourceVersion,omitempty"`
+}
+
+// DeleteOptions are the options of a delete request, encoded like kubernetes DeleteOptions.
+type DeleteOptions struct {
+	// GracePeriodSeconds is the duration before the object is deleted, zero deletes it immediately.
+	GracePeriodSeconds *int64               `json:"gracePeriodSeconds,omitempty"`
+	Preconditions      *Preconditions       `json:"preconditions,omitempty"`
+	PropagationPolicy  *DeletionPropagation `json:"propagationPolicy,omitempty"`
+	// DryRun is DryRunAll to process the request without deleting anything.
+	DryRun []string `json:"dryRun,omitempty"`
+	// InBody sends the options as a json body instead of query parameters.
+	// Preconditions are only sent in body.
+	InBody bool `json:"-"`
+}
+
+// ApplyToRequest implements Option
+func (options *DeleteOptions) ApplyToRequest(req *rest.Request) *rest.Request {
+	return applyQuery(options.ApplyWithoutQuery(req), options.QueryParams())
+}
+
+// QueryParams implements QueryOption, there are none if the options are sent in body.
+func (options *DeleteOptions) QueryParams() url.Values {
+	query := url.Values{}
+	if nil == options || options.inBody() {
+		return query
+	}
+	if nil != options.GracePeriodSeconds {
+		query.Set("gracePeriodSeconds", strconv.FormatInt(*options.GracePeriodSeconds, 10))
+	}
+	if nil != options.PropagationPolicy {
+		query.Set("propagationPolicy", string(*options.PropagationPolicy))
+	}
+	for _, dryRun := range options.DryRun {
+		query.Add("dryRun", dryRun)
+	}
+	return query
+}
+
+// ApplyWithoutQuery implements QueryOption, it sets the body of the options sent in body.
+func (options *DeleteOptions) ApplyWithoutQuery(req *rest.Request) *rest.Request {
+	if nil == options || !options.inBody() {
+		return req
+	}
+	// the options are plain values, they are always encoded
+	body, _ := json.Marshal(struct {
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+		*DeleteOptions
+	}{"DeleteOptions", "v1", options})
+	return req.SetHeader("Content-Type", "application/json").Body(body)
+}
+
+// inBody returns whether the options are sent as a json body, preconditions are only sent in body.
+func (options *DeleteOptions) inBody() bool {
+	return options.InBody || nil != options.Preconditions
+}
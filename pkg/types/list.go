@@ -0,0 +1,68 @@
+package types
+
+import (
+	"net/url"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+)
+
+// ListOptions are the options of a list or watch request, encoded like kubernetes ListOptions.
+type ListOptions struct {
+	// LabelSelector selects the objects by their labels, e.g. labels.SelectorFromSet.
+	LabelSelector labels.Selector
+	// FieldSelector selects the objects by their fields, e.g. fields.OneTermEqualSelector.
+	FieldSelector fields.Selector
+	// Limit is the maximum number of objects of a page, zero lists all objects.
+	Limit int64
+	// Continue is the continue token of the next page.
+	Continue string
+	// ResourceVersion is the version the objects are listed or watched from.
+	ResourceVersion string
+	// TimeoutSeconds bounds the duration of the request, a watch in particular.
+	TimeoutSeconds *int64
+	// Params are additional query parameters, a key with several values is sent repeatedly.
+	Params url.Values
+}
+
+// ApplyToRequest implements Option
+func (options *ListOptions) ApplyToRequest(req *rest.Request) *rest.Request {
+	return applyQuery(req, options.QueryParams())
+}
+
+// QueryParams implements QueryOption
+func (options *ListOptions) QueryParams() url.Values {
+	query := url.Values{}
+	if nil == options {
+		return query
+	}
+	if nil != options.LabelSelector && !options.LabelSelector.Empty() {
+		query.Set("labelSelector", options.LabelSelector.String())
+	}
+	if nil != options.FieldSelector && !options.FieldSelector.Empty() {
+		query.Set("fieldSelector", options.FieldSelector.String())
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.FormatInt(options.Limit, 10))
+	}
+	if len(options.Continue) > 0 {
+		query.Set("continue", options.Continue)
+	}
+	if len(options.ResourceVersion) > 0 {
+		query.Set("resourceVersion", options.ResourceVersion)
+	}
+	if nil != options.TimeoutSeconds {
+		query.Set("timeoutSeconds", strconv.FormatInt(*options.TimeoutSeconds, 10))
+	}
+	for k, values := range options.Params {
+		query[k] = append(query[k], values...)
+	}
+	return query
+}
+
+// ApplyWithoutQuery implements QueryOption, list options are all query parameters.
+func (options *ListOptions) ApplyWithoutQuery(req *rest.Request) *rest.Request {
+	return req
+}
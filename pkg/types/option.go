@@ -2,6 +2,8 @@ package types
 
 import (
 	"net/url"
+	"sort"
+	"time"
 
 	"k8s.io/client-go/rest"
 )
@@ -14,25 +16,99 @@ type Option interface {
 	ApplyToRequest(req *rest.Request) *rest.Request
 }
 
+// QueryOption is an Option that tells the query parameters it sets, so that they could be
+// merged with those of other options before they are set, see Merge.
+type QueryOption interface {
+	Option
+	// QueryParams returns the query parameters set by the option.
+	QueryParams() url.Values
+	// ApplyWithoutQuery applies the option to req, except its query parameters.
+	ApplyWithoutQuery(req *rest.Request) *rest.Request
+}
+
+// Idempotent is an Option that tells whether the request could be safely retried.
+// GET, PUT and DELETE requests are always considered idempotent, POST and PATCH
+// requests are only retried if their option says so.
+type Idempotent interface {
+	IsIdempotent() bool
+}
+
+// IsIdempotent returns whether option marks the request idempotent.
+func IsIdempotent(option Option) bool {
+	if i, ok := option.(Idempotent); ok {
+		return i.IsIdempotent()
+	}
+	return false
+}
+
 // Options ...
 type Options struct {
 	Header url.Values
 	Params QueryParameters
+	// Query are the query parameters with several values, e.g. ?tag=a&tag=b, which are
+	// sent in their order after the value of the same key in Params, if any.
+	Query url.Values
+	// Idempotent marks a POST or PATCH request safe to retry.
+	Idempotent bool
+	// Timeout bounds the duration of the request, it's also sent as the "timeout" parameter.
+	Timeout time.Duration
+}
+
+// IsIdempotent implements Idempotent
+func (options *Options) IsIdempotent() bool {
+	return nil != options && options.Idempotent
 }
 
 // ApplyToRequest apply options to rest request
 func (options *Options) ApplyToRequest(req *rest.Request) *rest.Request {
+	return applyQuery(options.ApplyWithoutQuery(req), options.QueryParams())
+}
+
+// QueryParams implements QueryOption, the values of Query follow that of Params for the same key.
+func (options *Options) QueryParams() url.Values {
+	query := url.Values{}
 	if nil != options {
-		if headers := options.Header; nil != headers {
-			for k, v := range headers {
-				req = req.SetHeader(k, v...)
-			}
+		for k, v := range options.Params {
+			query.Add(k, v)
 		}
-		if params := options.Params; nil != params {
-			for k, v := range params {
-				req = req.Param(k, v)
-			}
+		for k, values := range options.Query {
+			query[k] = append(query[k], values...)
+		}
+	}
+	return query
+}
+
+// ApplyWithoutQuery implements QueryOption
+func (options *Options) ApplyWithoutQuery(req *rest.Request) *rest.Request {
+	if nil != options {
+		// keys are applied in order so that requests are the same for the same options
+		for _, k := range sortedKeys(options.Header) {
+			req = req.SetHeader(k, options.Header[k]...)
+		}
+		if options.Timeout > 0 {
+			req = req.Timeout(options.Timeout)
+		}
+	}
+	return req
+}
+
+// applyQuery adds the query parameters query to req.
+func applyQuery(req *rest.Request, query url.Values) *rest.Request {
+	// keys are applied in order so that requests are the same for the same options
+	for _, k := range sortedKeys(query) {
+		for _, v := range query[k] {
+			req = req.Param(k, v)
 		}
 	}
 	return req
 }
+
+// sortedKeys returns the keys of values in order.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed request is retried.
+//
+// Only idempotent requests are retried: GET, PUT and DELETE requests always,
+// POST and PATCH requests if their option is Idempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts, zero means no limit.
+	MaxBackoff time.Duration
+	// Multiplier grows the back-off after every retry.
+	Multiplier float64
+	// Jitter adds a random wait of up to Jitter times the back-off.
+	Jitter float64
+	// RetryableStatusCodes are the status codes of responses worth retrying.
+	// Requests failed without any response are always retried.
+	RetryableStatusCodes []int
+	// HonorRetryAfter waits as long as the Retry-After header of the response asks,
+	// if it's longer than the back-off.
+	HonorRetryAfter bool
+}
+
+// DefaultRetryPolicy returns a policy retrying throttled and unavailable requests
+// up to 3 attempts with exponential back-off.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2.0,
+		Jitter:         0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		HonorRetryAfter: true,
+	}
+}
+
+// Backoff returns how long to wait before the next attempt after attempt failed
+// with a response of statusCode and header, statusCode is zero without a response.
+// It returns false if the request should not be retried.
+func (p *RetryPolicy) Backoff(attempt int, statusCode int, header http.Header) (time.Duration, bool) {
+	if nil == p || attempt >= p.MaxAttempts || !p.retryable(statusCode) {
+		return 0, false
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait := time.Duration(float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1)))
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		wait += time.Duration(rand.Float64() * p.Jitter * float64(wait))
+	}
+	if p.HonorRetryAfter {
+		if after, ok := RetryAfter(header); ok && after > wait {
+			wait = after
+		}
+	}
+	return wait, true
+}
+
+func (p *RetryPolicy) retryable(statusCode int) bool {
+	if 0 == statusCode {
+		return true
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryAfter parses the Retry-After header of header in either seconds or http date.
+func RetryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); nil == err {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); nil == err {
+		return time.Until(date), true
+	}
+	return 0, false
+}
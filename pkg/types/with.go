@@ -0,0 +1,111 @@
+package types
+
+import (
+	"encoding/base64"
+	"net/url"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// WithHeader sets the header key to values.
+func WithHeader(key string, values ...string) *Options {
+	return &Options{Header: url.Values{key: values}}
+}
+
+// WithParam sets the query parameter key to value.
+func WithParam(key, value string) *Options {
+	return &Options{Params: QueryParameters{key: value}}
+}
+
+// WithParams sets the query parameters params.
+func WithParams(params QueryParameters) *Options {
+	return &Options{Params: params}
+}
+
+// WithQuery adds the query parameter key with values, e.g. a repeated ?tag=a&tag=b.
+func WithQuery(key string, values ...string) *Options {
+	return &Options{Query: url.Values{key: values}}
+}
+
+// WithBasicAuth sets the Authorization header of basic authentication.
+func WithBasicAuth(username, password string) *Options {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return WithHeader("Authorization", "Basic "+auth)
+}
+
+// WithTimeout bounds the duration of the request.
+func WithTimeout(timeout time.Duration) *Options {
+	return &Options{Timeout: timeout}
+}
+
+// WithDryRun processes the request without persisting anything.
+func WithDryRun() *Options {
+	return WithParam("dryRun", DryRunAll)
+}
+
+// Merge combines options into one Option, nil ones are skipped.
+//
+// The options are applied in order, so that later ones take precedence: their headers
+// replace those of earlier ones, and the query parameters of QueryOptions replace the
+// values earlier ones set for the same keys. The parameters of the other options are
+// added to those. The merged option is idempotent if any of options is.
+func Merge(options ...Option) Option {
+	merged := &mergedOption{}
+	for _, option := range options {
+		switch o := option.(type) {
+		case nil:
+		case *mergedOption:
+			merged.options = append(merged.options, o.options...)
+		default:
+			merged.options = append(merged.options, o)
+		}
+	}
+	return merged
+}
+
+type mergedOption struct {
+	options []Option
+}
+
+// ApplyToRequest implements Option
+func (o *mergedOption) ApplyToRequest(req *rest.Request) *rest.Request {
+	return applyQuery(o.ApplyWithoutQuery(req), o.QueryParams())
+}
+
+// QueryParams implements QueryOption, the values of later options replace those of earlier
+// ones for the same keys.
+func (o *mergedOption) QueryParams() url.Values {
+	query := url.Values{}
+	for _, option := range o.options {
+		if q, ok := option.(QueryOption); ok {
+			for k, values := range q.QueryParams() {
+				query[k] = values
+			}
+		}
+	}
+	return query
+}
+
+// ApplyWithoutQuery implements QueryOption, the options which are not QueryOptions are
+// applied as a whole.
+func (o *mergedOption) ApplyWithoutQuery(req *rest.Request) *rest.Request {
+	for _, option := range o.options {
+		if q, ok := option.(QueryOption); ok {
+			req = q.ApplyWithoutQuery(req)
+		} else {
+			req = option.ApplyToRequest(req)
+		}
+	}
+	return req
+}
+
+// IsIdempotent implements Idempotent
+func (o *mergedOption) IsIdempotent() bool {
+	for _, option := range o.options {
+		if IsIdempotent(option) {
+			return true
+		}
+	}
+	return false
+}